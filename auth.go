@@ -0,0 +1,52 @@
+package sse
+
+import "net/http"
+
+// AuthToken is a credential set on requests, such as *oauth2.Token of
+// golang.org/x/oauth2 or BearerToken
+type AuthToken interface {
+	SetAuthHeader(req *http.Request)
+}
+
+// TokenSource supplies the token of every connection attempt. Its method set
+// is that of golang.org/x/oauth2.TokenSource with *oauth2.Token as T, so any
+// oauth2 token source can be used, e.g.
+//
+//	sse.WithTokenSource[*oauth2.Token](config.TokenSource(ctx, token))
+//
+// Token sources should cache tokens until they expire, as oauth2's do.
+type TokenSource[T AuthToken] interface {
+	Token() (T, error)
+}
+
+// BearerToken is an AuthToken sent in the Authorization header
+type BearerToken string
+
+// SetAuthHeader sets the Authorization header of req to the bearer token
+func (t BearerToken) SetAuthHeader(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+}
+
+// TokenSourceFunc adapts a function to a TokenSource of bearer tokens
+type TokenSourceFunc func() (BearerToken, error)
+
+// Token calls f
+func (f TokenSourceFunc) Token() (BearerToken, error) {
+	return f()
+}
+
+// WithTokenSource authenticates every connection attempt with a token from
+// ts, so streams outliving their tokens reconnect with fresh ones. A failure
+// to get a token fails the attempt. It sets Client.Authenticate.
+func WithTokenSource[T AuthToken](ts TokenSource[T]) ClientOption {
+	return func(c *Client) {
+		c.Authenticate = func(req *http.Request) error {
+			token, err := ts.Token()
+			if err != nil {
+				return err
+			}
+			token.SetAuthHeader(req)
+			return nil
+		}
+	}
+}
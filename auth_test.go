@@ -0,0 +1,73 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// oauthToken mimics *oauth2.Token, which sets its own type of authorization
+type oauthToken struct {
+	TokenType   string
+	AccessToken string
+}
+
+func (t *oauthToken) SetAuthHeader(req *http.Request) {
+	req.Header.Set("Authorization", t.TokenType+" "+t.AccessToken)
+}
+
+// oauthTokenSource mimics oauth2.TokenSource
+type oauthTokenSource interface {
+	Token() (*oauthToken, error)
+}
+
+type refreshingSource struct {
+	refreshes int
+}
+
+func (s *refreshingSource) Token() (*oauthToken, error) {
+	s.refreshes++
+	return &oauthToken{TokenType: "Bearer", AccessToken: "token" + strconv.Itoa(s.refreshes)}, nil
+}
+
+func TestWithTokenSource(t *testing.T) {
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Authorization")
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	var ts oauthTokenSource = &refreshingSource{}
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond), WithTokenSource[*oauthToken](ts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := collect(c.Subscribe(ctx, server.URL))
+
+	equals(t, "Bearer token1", <-received)
+	<-results
+	<-results
+	equals(t, "Bearer token2", <-received)
+}
+
+func TestTokenSourceFunc(t *testing.T) {
+	c := NewClient(http.DefaultClient, WithTokenSource[BearerToken](TokenSourceFunc(func() (BearerToken, error) {
+		return "secret", nil
+	})))
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	ok(t, err)
+	req, err = c.prepareRequest(req)
+	ok(t, err)
+	equals(t, "Bearer secret", req.Header.Get("Authorization"))
+
+	c = NewClient(http.DefaultClient, WithTokenSource[BearerToken](TokenSourceFunc(func() (BearerToken, error) {
+		return "", errors.New("expired refresh token")
+	})))
+	_, err = c.prepareRequest(req)
+	equals(t, "expired refresh token", err.Error())
+}
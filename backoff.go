@@ -0,0 +1,106 @@
+package sse
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// ReconnectPolicy decides how long a stream waits before reconnecting.
+// attempt is the number of attempts that failed since the last connection was
+// open, starting at 1, lastErr the error of the last one and resp its
+// response, nil if it got none or was accepted. NextDelay returns false to
+// stop reconnecting, making lastErr the terminal error of the stream.
+// A policy is shared by every stream of a Client, so it must be safe for
+// concurrent use. It is only consulted for errors the StatusPolicy, or
+// ErrorClassifier, retries without a delay of their own.
+type ReconnectPolicy interface {
+	NextDelay(attempt int, lastErr error, resp *http.Response) (time.Duration, bool)
+}
+
+// ReconnectPolicyFunc adapts a function to a ReconnectPolicy
+type ReconnectPolicyFunc func(attempt int, lastErr error, resp *http.Response) (time.Duration, bool)
+
+// NextDelay calls f
+func (f ReconnectPolicyFunc) NextDelay(attempt int, lastErr error, resp *http.Response) (time.Duration, bool) {
+	return f(attempt, lastErr, resp)
+}
+
+// ConstantBackoff waits Delay before every reconnect
+type ConstantBackoff struct {
+	Delay time.Duration
+	// MaxAttempts is the number of consecutive failed attempts after which
+	// the stream stops, zero for no limit
+	MaxAttempts int
+}
+
+// NextDelay returns Delay
+func (b ConstantBackoff) NextDelay(attempt int, lastErr error, resp *http.Response) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+	return b.Delay, true
+}
+
+// ExponentialBackoff multiplies the delay by Multiplier after every failed attempt
+type ExponentialBackoff struct {
+	// Initial is the delay of the first attempt
+	Initial time.Duration
+	// Max caps the delay, zero for no cap
+	Max time.Duration
+	// Multiplier is 2 if zero
+	Multiplier float64
+	// MaxAttempts is the number of consecutive failed attempts after which
+	// the stream stops, zero for no limit
+	MaxAttempts int
+}
+
+// NextDelay returns Initial * Multiplier^(attempt-1), capped at Max
+func (b ExponentialBackoff) NextDelay(attempt int, lastErr error, resp *http.Response) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	return capDelay(float64(b.Initial)*math.Pow(multiplier, float64(attempt-1)), b.Max), true
+}
+
+// DecorrelatedJitter spreads the reconnects of many clients after an outage
+// by picking a random delay between Base and three times the previous
+// delay, capped at Max. A policy shared by several streams doesn't know the
+// previous delay of each, so the upper bound is taken as Base * 3^attempt,
+// which the previous delay is expected to approach.
+type DecorrelatedJitter struct {
+	Base time.Duration
+	// Max caps the delay, zero for no cap
+	Max time.Duration
+	// MaxAttempts is the number of consecutive failed attempts after which
+	// the stream stops, zero for no limit
+	MaxAttempts int
+}
+
+// NextDelay returns a random delay between Base and Base * 3^attempt, capped at Max
+func (b DecorrelatedJitter) NextDelay(attempt int, lastErr error, resp *http.Response) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+	upper := capDelay(float64(b.Base)*math.Pow(3, float64(attempt)), b.Max)
+	if upper <= b.Base {
+		return upper, true
+	}
+	return b.Base + time.Duration(rand.Int63n(int64(upper-b.Base))), true
+}
+
+// capDelay converts a delay to a Duration no longer than max, if set
+func capDelay(delay float64, max time.Duration) time.Duration {
+	if max > 0 && delay > float64(max) {
+		return max
+	}
+	if delay > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
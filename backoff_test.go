@@ -0,0 +1,97 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff_NextDelay(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Second, Max: 10 * time.Second, MaxAttempts: 5}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+		retry    bool
+	}{
+		{1, time.Second, true},
+		{2, 2 * time.Second, true},
+		{3, 4 * time.Second, true},
+		{4, 8 * time.Second, true},
+		{5, 10 * time.Second, true},
+		{6, 0, false},
+	}
+	for _, tt := range tests {
+		delay, retry := b.NextDelay(tt.attempt, nil, nil)
+		equals(t, tt.expected, delay)
+		equals(t, tt.retry, retry)
+	}
+
+	delay, _ := ExponentialBackoff{Initial: time.Second, Multiplier: 1.5}.NextDelay(3, nil, nil)
+	equals(t, 2250*time.Millisecond, delay)
+	delay, _ = ExponentialBackoff{Initial: time.Second}.NextDelay(1000, nil, nil)
+	assert(t, delay > 0, "huge delays shouldn't overflow, got %v", delay)
+}
+
+func TestConstantBackoff_NextDelay(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second, MaxAttempts: 2}
+	delay, retry := b.NextDelay(2, nil, nil)
+	equals(t, time.Second, delay)
+	assert(t, retry, "should retry within MaxAttempts")
+	_, retry = b.NextDelay(3, nil, nil)
+	assert(t, !retry, "shouldn't retry past MaxAttempts")
+}
+
+func TestDecorrelatedJitter_NextDelay(t *testing.T) {
+	b := DecorrelatedJitter{Base: 10 * time.Millisecond, Max: time.Second}
+	for attempt := 1; attempt < 20; attempt++ {
+		delay, retry := b.NextDelay(attempt, nil, nil)
+		assert(t, retry, "should always retry without MaxAttempts")
+		assert(t, delay >= b.Base && delay <= b.Max, "attempt %d: delay %v out of bounds", attempt, delay)
+	}
+}
+
+func TestClient_ReconnectPolicy(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1)%2 == 1 {
+			w.Header().Set("X-Reason", "busy")
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	var attempts []int
+	var reasons []string
+	c := NewClient(server.Client(), WithReconnect(time.Hour))
+	c.ReconnectPolicy = ReconnectPolicyFunc(func(attempt int, lastErr error, resp *http.Response) (time.Duration, bool) {
+		attempts = append(attempts, attempt)
+		if resp != nil {
+			reasons = append(reasons, resp.Header.Get("X-Reason"))
+		}
+		return time.Millisecond, len(attempts) < 3
+	})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	var errs []error
+	for result := range collect(c.Stream(req.WithContext(context.Background()))) {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+
+	// a connection that was open resets the attempts, its end is the first
+	// failure of the next series
+	equals(t, []int{1, 1, 2}, attempts)
+	equals(t, []string{"busy", "busy"}, reasons)
+	equals(t, 3, len(errs))
+	equals(t, ErrStreamIsClosed, errs[1])
+	code, _ := StatusCode(errs[2])
+	equals(t, http.StatusBadGateway, code)
+}
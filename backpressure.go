@@ -0,0 +1,47 @@
+package sse
+
+import "context"
+
+// OverflowPolicy is what the event queue of a stream does with events once it is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops reading the stream until the consumer catches up
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued event to make room for the new one
+	OverflowDropOldest
+	// OverflowDropNewest drops the events read while the queue is full
+	OverflowDropNewest
+	// OverflowCoalesce replaces a queued event by a newer event with the same
+	// ID, so the consumer only gets the latest version of each, and blocks
+	// like OverflowBlock when the queue is full of distinct events.
+	// Coalescing happens whether or not the queue is full.
+	OverflowCoalesce
+)
+
+// Backpressure is how events are buffered between a stream's connection and
+// its consumer. Errors are never dropped nor coalesced.
+type Backpressure struct {
+	// QueueSize is the number of events that can be queued, see Client.EventQueueSize
+	QueueSize int
+	// Overflow is what happens once the queue is full
+	Overflow OverflowPolicy
+}
+
+type backpressureKey struct{}
+
+// WithBackpressure returns a copy of ctx carrying bp. Streams started from a
+// request with this context use bp instead of the Client's EventQueueSize and
+// QueueOverflow, e.g. to drop events of a high-frequency stream rather than
+// stalling it when its consumer falls behind.
+func WithBackpressure(ctx context.Context, bp Backpressure) context.Context {
+	return context.WithValue(ctx, backpressureKey{}, bp)
+}
+
+// backpressure returns the backpressure of the stream of ctx
+func (c *Client) backpressure(ctx context.Context) Backpressure {
+	if bp, ok := ctx.Value(backpressureKey{}).(Backpressure); ok {
+		return bp
+	}
+	return Backpressure{QueueSize: c.EventQueueSize, Overflow: c.QueueOverflow}
+}
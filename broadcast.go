@@ -0,0 +1,134 @@
+package sse
+
+import "context"
+
+// Broadcast shares a single stream between any number of subscribers, each
+// getting its own channels and its own buffer, so a stream consumed by
+// several goroutines takes a single connection:
+//
+//	broadcast := sse.NewBroadcast(client.Stream(req))
+//	events, errs := broadcast.Subscribe(ctx, sse.Backpressure{QueueSize: 100})
+//
+// Every subscriber gets every event and error received after it subscribed.
+// Subscribers falling behind are handled by the overflow policy of their
+// Backpressure: OverflowBlock stalls the stream, and every other subscriber,
+// until they catch up, while the drop policies only cost the lagging
+// subscriber events. The stream is stopped as usual, e.g. with the context
+// of its request.
+type Broadcast struct {
+	join  chan *broadcastSubscriber
+	leave chan *broadcastSubscriber
+	// done is closed once the stream has ended
+	done chan struct{}
+}
+
+// broadcastSubscriber is a subscriber of a Broadcast, buffered by its queue
+type broadcastSubscriber struct {
+	eventch chan *Event
+	errch   chan error
+	queue   *deliveryQueue
+}
+
+// NewBroadcast starts sharing the stream of eventch and errch, as returned
+// by Client.Stream, between subscribers. Events received while there are no
+// subscribers are dropped.
+func NewBroadcast(eventch <-chan *Event, errch <-chan error) *Broadcast {
+	b := &Broadcast{
+		join:  make(chan *broadcastSubscriber),
+		leave: make(chan *broadcastSubscriber),
+		done:  make(chan struct{}),
+	}
+	go b.run(eventch, errch)
+	return b
+}
+
+// Subscribe returns channels receiving the events and errors of the stream,
+// buffered and overflowing according to bp. At least one event is buffered.
+// Both channels are closed once the stream has ended, or ctx is done; the
+// channels of a subscriber joining after the stream ended are closed already.
+func (b *Broadcast) Subscribe(ctx context.Context, bp Backpressure) (<-chan *Event, <-chan error) {
+	if bp.QueueSize < 1 {
+		bp.QueueSize = 1
+	}
+	sub := &broadcastSubscriber{
+		eventch: make(chan *Event),
+		errch:   make(chan error),
+	}
+	sub.queue = newDeliveryQueue(bp, func(*Event) bool { return false }, nil, nil, sub.eventch, sub.errch, nil, ctx.Done())
+
+	select {
+	case b.join <- sub:
+	case <-b.done:
+		sub.close()
+		return sub.eventch, sub.errch
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case b.leave <- sub:
+			case <-b.done:
+			}
+		case <-b.done:
+		}
+	}()
+	return sub.eventch, sub.errch
+}
+
+// Done returns a channel closed once the stream has ended. The channels of
+// the subscribers are closed once they have received what was buffered.
+func (b *Broadcast) Done() <-chan struct{} {
+	return b.done
+}
+
+// run fans the stream out to the subscribers until it ends. Subscribers are
+// only touched from here, so none is closed while a result is pushed to it.
+func (b *Broadcast) run(eventch <-chan *Event, errch <-chan error) {
+	subscribers := make(map[*broadcastSubscriber]struct{})
+	defer close(b.done)
+
+	for eventch != nil || errch != nil {
+		select {
+		case sub := <-b.join:
+			subscribers[sub] = struct{}{}
+		case sub := <-b.leave:
+			delete(subscribers, sub)
+			go sub.close()
+		case event, open := <-eventch:
+			if !open {
+				eventch = nil
+				continue
+			}
+			for sub := range subscribers {
+				// every subscriber gets its own copy, sharing Data, so it
+				// can't go back to the pool
+				copied := *event
+				copied.pooled = false
+				sub.queue.push(Result{Event: &copied})
+			}
+		case err, open := <-errch:
+			if !open {
+				errch = nil
+				continue
+			}
+			for sub := range subscribers {
+				sub.queue.push(Result{Err: err})
+			}
+		}
+	}
+
+	// subscribers are closed concurrently so one that isn't reading doesn't
+	// hold up the others
+	for sub := range subscribers {
+		go sub.close()
+	}
+}
+
+// close delivers what is left in the queue of the subscriber, unless it is
+// gone, then closes its channels
+func (sub *broadcastSubscriber) close() {
+	sub.queue.close()
+	close(sub.eventch)
+	close(sub.errch)
+}
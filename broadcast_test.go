@@ -0,0 +1,99 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBroadcast(t *testing.T) {
+	eventch := make(chan *Event)
+	errch := make(chan error)
+	broadcast := NewBroadcast(eventch, errch)
+
+	ctx := context.Background()
+	events1, errs1 := broadcast.Subscribe(ctx, Backpressure{QueueSize: 10})
+	events2, errs2 := broadcast.Subscribe(ctx, Backpressure{QueueSize: 10})
+
+	errFailed := errors.New("failed")
+	eventch <- &Event{Data: []byte("a")}
+	errch <- errFailed
+	eventch <- &Event{Data: []byte("b")}
+	close(eventch)
+	close(errch)
+
+	for _, sub := range []struct {
+		events <-chan *Event
+		errs   <-chan error
+	}{{events1, errs1}, {events2, errs2}} {
+		var data []string
+		var errs []error
+		for r := range collect(sub.events, sub.errs) {
+			if r.Event != nil {
+				data = append(data, string(r.Event.Data))
+			} else {
+				errs = append(errs, r.Err)
+			}
+		}
+		equals(t, []string{"a", "b"}, data)
+		equals(t, []error{errFailed}, errs)
+	}
+
+	<-broadcast.Done()
+	events, errs := broadcast.Subscribe(ctx, Backpressure{})
+	_, open := <-events
+	assert(t, !open, "expected the events of a late subscriber to be closed")
+	_, open = <-errs
+	assert(t, !open, "expected the errors of a late subscriber to be closed")
+}
+
+func TestBroadcast_laggingSubscriber(t *testing.T) {
+	eventch := make(chan *Event)
+	errch := make(chan error)
+	broadcast := NewBroadcast(eventch, errch)
+
+	ctx := context.Background()
+	lagging, laggingErrs := broadcast.Subscribe(ctx, Backpressure{QueueSize: 2, Overflow: OverflowDropOldest})
+	events, _ := broadcast.Subscribe(ctx, Backpressure{QueueSize: 1})
+
+	// the lagging subscriber doesn't read, which doesn't stall the other one
+	for _, data := range []string{"a", "b", "c", "d"} {
+		eventch <- &Event{Data: []byte(data)}
+		equals(t, data, string((<-events).Data))
+	}
+	close(eventch)
+	close(errch)
+
+	var data []string
+	for r := range collect(lagging, laggingErrs) {
+		data = append(data, string(r.Event.Data))
+	}
+	equals(t, []string{"c", "d"}, data)
+}
+
+func TestBroadcast_unsubscribe(t *testing.T) {
+	eventch := make(chan *Event)
+	errch := make(chan error)
+	broadcast := NewBroadcast(eventch, errch)
+	defer close(errch)
+	defer close(eventch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs := broadcast.Subscribe(ctx, Backpressure{})
+	stayed, _ := broadcast.Subscribe(context.Background(), Backpressure{})
+	cancel()
+
+	select {
+	case _, open := <-events:
+		assert(t, !open, "expected no event")
+	case <-time.After(time.Second):
+		t.Fatal("expected the events of the subscriber to be closed")
+	}
+	_, open := <-errs
+	assert(t, !open, "expected the errors of the subscriber to be closed")
+
+	// the stream goes on for the other subscribers
+	eventch <- &Event{Data: []byte("a")}
+	equals(t, "a", string((<-stayed).Data))
+}
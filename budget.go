@@ -0,0 +1,50 @@
+package sse
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryBudget is a token bucket of reconnect attempts that can be shared by
+// many streams. Every reconnect takes a token; when the bucket is empty, streams
+// wait for their turn as tokens are refilled, spreading reconnects over time.
+type RetryBudget struct {
+	mutex    sync.Mutex
+	capacity float64
+	tokens   float64
+	interval time.Duration
+	last     time.Time
+}
+
+// NewRetryBudget creates a full budget of capacity reconnect attempts,
+// refilling one token every interval
+func NewRetryBudget(capacity int, interval time.Duration) *RetryBudget {
+	return &RetryBudget{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		interval: interval,
+		last:     time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long to wait before it may be used
+func (b *RetryBudget) reserve() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	if b.interval > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+	}
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	// going negative makes later callers wait behind earlier ones
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.interval))
+}
@@ -0,0 +1,19 @@
+package sse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBudget_reserve(t *testing.T) {
+	budget := NewRetryBudget(2, time.Hour)
+
+	equals(t, time.Duration(0), budget.reserve())
+	equals(t, time.Duration(0), budget.reserve())
+
+	// the bucket is empty, so callers queue up one interval apart
+	wait := budget.reserve()
+	assert(t, wait > 59*time.Minute && wait <= time.Hour, "unexpected wait %s", wait)
+	wait = budget.reserve()
+	assert(t, wait > 119*time.Minute && wait <= 2*time.Hour, "unexpected wait %s", wait)
+}
@@ -1,112 +1,1027 @@
 package sse
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 )
 
 var (
 	// ErrStreamIsClosed is passed to the user when the stream returns an EOF
 	ErrStreamIsClosed = errors.New("Stream has closed")
+
+	// ErrBodyNotReplayable is the terminal error of a stream whose request has a
+	// body that can't be sent again to reconnect, because its GetBody isn't set
+	ErrBodyNotReplayable = errors.New("request body can't be sent again to reconnect")
+
+	// ErrEventTooLarge is the terminal error of a stream sending an event
+	// larger than Client.MaxEventSize, or returned by a Decoder reading one.
+	// Streams don't reconnect after it since they would get the event again.
+	ErrEventTooLarge = errors.New("event too large")
+
+	// ErrStreamingUnsupported is the terminal error of streams in a
+	// WebAssembly runtime whose fetch can't stream response bodies, which
+	// net/http would then wait for in full
+	ErrStreamingUnsupported = errors.New("fetch can't stream response bodies")
+
+	// ErrClientClosed is the terminal error of streams started after the
+	// Client was closed
+	ErrClientClosed = errors.New("client is closed")
+
+	// errStreamStopped is used internally when a stream ends without an error to report
+	errStreamStopped = errors.New("stream stopped")
 )
 
+// DefaultReconnectDelay is the delay before reconnecting when Client.ReconnectDelay isn't set
+const DefaultReconnectDelay = 3 * time.Second
+
 // Client is a struct to use to stream event
 type Client struct {
-	HTTPClient         *http.Client
-	currentlyStreaming map[chan *Event]chan bool
-	mutex              sync.Mutex
+	HTTPClient *http.Client
+
+	// HeartbeatOnComment delivers a synthetic heartbeat event whenever the
+	// server sends a block made up only of comments (e.g. ": keep-alive")
+	HeartbeatOnComment bool
+	// HeartbeatInterval delivers a synthetic heartbeat event every interval
+	// while the connection is open. Zero disables it.
+	HeartbeatInterval time.Duration
+
+	// DefaultEventType, if set, is the Type of events without an event field,
+	// e.g. MessageEventType as the spec dispatches them, so routing on types
+	// works with servers leaving the field out. Empty leaves their Type empty.
+	DefaultEventType string
+
+	// CarryLastEventID sets the LastEventID of events without an id field to
+	// the ID of the last event that had one, as the spec does, until an
+	// empty id field resets it. Otherwise LastEventID is only set on the
+	// events that have an id field. Note that OverflowCoalesce then merges
+	// the events carrying the same ID.
+	CarryLastEventID bool
+	// InitialBufferSize is the initial size of the buffer events are read
+	// into, 4KB if zero. It grows as needed up to MaxEventSize.
+	InitialBufferSize int
+	// MaxEventSize is the size of the largest event that can be read,
+	// including its field names, 64KB if zero. Larger events end the stream
+	// with ErrEventTooLarge.
+	MaxEventSize int
+	// InvalidUTF8 is how events that aren't valid UTF-8 are handled, passed
+	// through as they are by default
+	InvalidUTF8 UTF8Policy
+	// DispatchEmptyEvents delivers events without data, such as blocks made
+	// up only of comments or of an id field, which the spec has dropped
+	DispatchEmptyEvents bool
+	// PoolEvents reuses the events delivered once the consumer gives them
+	// back with Event.Release, so high throughput streams don't allocate an
+	// event per message. Consumers must release every event once done with
+	// it, and not use it afterwards, see Event.Clone.
+	PoolEvents bool
+
+	// ErrorEventTypes lists event types (e.g. "error") that are converted into
+	// an *EventError on the error channel instead of being delivered as events
+	ErrorEventTypes []string
+	// StopOnErrorEvent ends the stream after an error event has been passed
+	// through the error channel
+	StopOnErrorEvent bool
+
+	// TimestampField is a non-standard field (e.g. "ts") the server uses to
+	// send the time an event was produced. It is parsed into Event.Timestamp.
+	TimestampField string
+	// TimestampJSONPath is a dot separated path (e.g. "meta.sent_at") into the
+	// JSON data of an event holding its timestamp. Only used when
+	// TimestampField is empty.
+	TimestampJSONPath string
+	// LatencyObserver, if set, is called with the end to end latency of every
+	// event that has a Timestamp
+	LatencyObserver func(event *Event, latency time.Duration)
+	// Metrics, if set, receives measurements of every stream: connects,
+	// reconnects, bytes read, events and their end to end latency
+	Metrics Metrics
+	// Tracer, if set, traces every stream and its connection attempts
+	Tracer Tracer
+	// TraceEvents adds a span event for every event received to the span of
+	// its connection
+	TraceEvents bool
+	// Logger, if set, is told about connections, disconnections, reconnects,
+	// parse errors and dropped events, see SlogLogger
+	Logger Logger
+
+	// EventQueueSize is the number of events (and errors) that can be queued
+	// between the connection and the consumer. Zero means nothing is queued
+	// and every event is handed to the consumer before the next one is read.
+	EventQueueSize int
+	// QueueOverflow is what the event queue does once it is full, blocking
+	// until the consumer catches up by default. Streams needing a different
+	// queue can set it on their request's context with WithBackpressure.
+	QueueOverflow OverflowPolicy
+	// PriorityEventTypes lists event types (e.g. "control") that skip the
+	// event queue and are delivered ahead of any queued events.
+	// It has no effect when EventQueueSize is zero.
+	PriorityEventTypes []string
+
+	// DeliveryDeadline is how long an event may wait to be received by the
+	// consumer (including time spent in the event queue) before the delivery is
+	// reported as slow to OnSlowDelivery. Zero disables the check.
+	DeliveryDeadline time.Duration
+	// OnSlowDelivery is called for every event exceeding the DeliveryDeadline,
+	// from the goroutine delivering events, so it should return quickly
+	OnSlowDelivery func(SlowDelivery)
+
+	// Header holds default headers sent with every request, unless the
+	// request sets them itself
+	Header http.Header
+	// Authenticate, if set, sets the credentials of the request of every
+	// connection attempt, including reconnects, see WithTokenSource
+	Authenticate func(req *http.Request) error
+	// BeforeConnect, if set, is called with the request of every connection
+	// attempt, including reconnects, after the default headers and the
+	// credentials are set. It
+	// may change the request, e.g. to set a fresh auth token or a correlation
+	// ID. An error fails the attempt as a connection error would.
+	BeforeConnect func(req *http.Request) error
+
+	// IdleTimeout, if set, is how long a connection may go without receiving
+	// any data, including comments, before it is considered stalled and
+	// closed with ErrStreamIdle, to be reconnected if Reconnect is set.
+	// It should be longer than the interval of the server's keep-alives.
+	IdleTimeout time.Duration
+	// ConnectTimeout, if set, is how long a connection attempt may take to get
+	// the response headers before it is abandoned with ErrConnectTimeout,
+	// unlike the Timeout of the http.Client which would also end the stream
+	ConnectTimeout time.Duration
+	// FirstEventTimeout, if set, is how long an open connection may go
+	// without delivering its first event, comments aside, before it is closed
+	// with ErrFirstEventTimeout
+	FirstEventTimeout time.Duration
+	// MaxConnectionAge, if set, is how long a connection is kept open before
+	// the stream reconnects, resuming from the last event ID, so connections
+	// are rotated at a predictable time rather than killed by a proxy at an
+	// awkward one. The reconnect happens right away and isn't reported as an
+	// error.
+	MaxConnectionAge time.Duration
+
+	// Reconnect makes streams reconnect after the connection fails or is closed
+	// by the server. The errors are still passed through the error channel, but
+	// the stream keeps going until it is stopped or the request's context is done.
+	Reconnect bool
+	// ReconnectDelay is the delay before reconnecting, DefaultReconnectDelay if zero.
+	// Once the server sends a retry field, its value is used instead, and a
+	// 429 or 503 response with a Retry-After header is retried after the
+	// delay it asks for.
+	ReconnectDelay time.Duration
+	// ReconnectPolicy, if set, decides the delay before every reconnect, and
+	// when to give up, instead of ReconnectDelay and the server's retry field,
+	// see ExponentialBackoff
+	ReconnectPolicy ReconnectPolicy
+	// RetryBudget, if set, limits the rate of reconnect attempts across every
+	// stream of the Client, so an outage doesn't make all of them hammer the
+	// server at once. It may also be shared between Clients.
+	RetryBudget *RetryBudget
+	// StatusPolicy decides which non-200 responses are reconnected,
+	// DefaultStatusPolicy() if nil. Streams needing a different policy can
+	// set it on their request's context with WithStatusPolicy.
+	StatusPolicy StatusPolicy
+	// ErrorClassifier, if set, is consulted on every failure of a reconnecting
+	// stream instead of the StatusPolicy
+	ErrorClassifier ErrorClassifier
+	// HealthProber, if set, probes the endpoint and its failover candidates
+	// once the reconnect delay is over, and keeps probing, waiting the
+	// ReconnectDelay between rounds, until one of them is healthy
+	HealthProber *HealthProber
+	// Failover, if set, moves reconnecting streams to other endpoints
+	// serving them once connection attempts keep failing
+	Failover *Failover
+
+	// ControlEventType is the type of the in-band control events (e.g. "control")
+	// the server uses to make the client reset, redirect or back off, see Control.
+	// Control events are applied by reconnecting, even without Reconnect set,
+	// and aren't delivered as events. Control events that can't be parsed are
+	// reported as *InvalidControlError. Empty disables control events.
+	ControlEventType string
+	// OnControl, if set, is called with every control directive applied
+	OnControl func(*Control)
+
+	// OnComment, if set, is called with the text of every comment the server
+	// sends, e.g. "keep-alive" for ": keep-alive", so consumers can
+	// implement their own liveness checks
+	OnComment func(req *http.Request, comment string)
+	// OnOpen, if set, is called whenever a connection is established, with
+	// its response, before any event is read from it
+	OnOpen func(resp *http.Response)
+	// OnError, if set, is called with every error passed through the error
+	// channel of a stream
+	OnError func(req *http.Request, err error)
+	// OnClose, if set, is called once a stream has terminated, with the error
+	// it ended with, nil if it was stopped
+	OnClose func(req *http.Request, err error)
+
+	// ContentTypeCheck is how strictly the Content-Type of responses is
+	// checked, ContentTypeLenient by default. Rejected responses end the
+	// connection with a *ContentTypeError.
+	ContentTypeCheck ContentTypeCheck
+
+	// Companion, if set, is a side-channel endpoint receiving heartbeats and
+	// acks while a connection is open
+	Companion *Companion
+
+	// DisableLastEventID stops reconnects from sending the ID of the last
+	// event received in the Last-Event-ID header, which they do by default so
+	// servers can resume the stream where it was left
+	DisableLastEventID bool
+	// LastEventID, if set, overrides the Last-Event-ID sent when reconnecting.
+	// It gets the ID of the last event received, empty if none was, and returns
+	// the ID to send, empty for none.
+	LastEventID func(received string) string
+	// Deduplicate, if set, orders event IDs to drop the events whose ID
+	// doesn't come after the ID of the last event received, such as events a
+	// server replays after a reconnect, see CompareNumericIDs. Events
+	// without an ID are always delivered.
+	Deduplicate EventIDComparator
+	// SequenceID, if set, parses the sequence number of event IDs, so a gap
+	// in the sequence is reported as a *SequenceGapError, see
+	// ParseSequenceID. IDs it can't parse are left out.
+	SequenceID func(id string) (uint64, bool)
+	// EventIDStore, if set, persists the ID of the last event received by
+	// every stream, so a stream started again, e.g. after a restart of the
+	// process, resumes from it. Requests setting their own Last-Event-ID
+	// header still resume from it instead.
+	EventIDStore EventIDStore
+
+	// ResumeFromOffset makes reconnects resume from the byte offset of the end
+	// of the last event received, for servers resuming by offset rather than
+	// event ID. The offset is sent as a "Range: bytes=<offset>-" header, and a
+	// 206 Partial Content response is accepted. A server ignoring Range and
+	// answering 200 sends the stream from the start, so the offset restarts at 0.
+	ResumeFromOffset bool
+	// OffsetHeader, if set, is a custom header the offset is sent in instead of Range
+	OffsetHeader string
+
+	// PayloadDecoding, if set, decodes compressed or encoded event data before
+	// delivering events. Events that fail to decode are replaced by a
+	// *PayloadError on the error channel.
+	PayloadDecoding *PayloadDecoding
+
+	// Tee, if set, receives a copy of the raw bytes of every stream as they are
+	// read, e.g. to record them to a file or relay them. It is shared by every
+	// stream of the Client: writes are serialized, but the bytes of concurrent
+	// streams are interleaved, so use WithTee for a writer per stream.
+	// A write error ends the connection.
+	Tee io.Writer
+
+	// CoalesceStreams makes streams of identical requests (same method, URL
+	// and headers, without a body) share a single upstream connection. Each
+	// call to Stream still gets its own channels receiving every event, and
+	// the connection is closed once all of them are stopped. Streams with
+	// per-stream settings in their context, such as StreamEventFilter, get
+	// their own connection, metadata aside, which each subscriber keeps.
+	CoalesceStreams bool
+
+	// streams are the running streams, by event channel, so StopStream can
+	// stop them. Everything else about a stream is owned by its goroutine.
+	streams       map[chan *Event]*activeStream
+	sharedStreams map[string]*sharedStream
+	// closed is set by Close, no stream starts afterwards
+	closed   bool
+	mutex    sync.Mutex
+	teeMutex sync.Mutex
 }
 
-// NewClient create a new sse client given a http.Client
-func NewClient(httpclient *http.Client) *Client {
-	return &Client{
-		HTTPClient:         httpclient,
-		currentlyStreaming: make(map[chan *Event]chan bool),
-		mutex:              sync.Mutex{},
+// activeStream is the bookkeeping the Client keeps about a running stream
+type activeStream struct {
+	// stopch is closed once the stream is stopped, by StopStream or because
+	// it has ended
+	stopch   chan struct{}
+	stopOnce sync.Once
+	// done is closed once the goroutine of the stream has exited
+	done chan struct{}
+}
+
+func newActiveStream() *activeStream {
+	return &activeStream{stopch: make(chan struct{}), done: make(chan struct{})}
+}
+
+// stop closes stopch, it may be called any number of times
+func (s *activeStream) stop() {
+	s.stopOnce.Do(func() { close(s.stopch) })
+}
+
+// NewClient create a new sse client given a http.Client, configured by opts
+func NewClient(httpclient *http.Client, opts ...ClientOption) *Client {
+	c := &Client{
+		HTTPClient:    httpclient,
+		streams:       make(map[chan *Event]*activeStream),
+		sharedStreams: make(map[string]*sharedStream),
+		mutex:         sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // Stream get events through a channel given a request
+// Metadata attached to the request's context with WithMetadata is set on every event
 // If ErrStreamIsClosed is passed through the error channel, the stream is disconnected/EOF
-func (c *Client) Stream(req *http.Request) (<-chan *Event, <-chan error) {
+// and, unless Reconnect is set, has ended.
+// Once the stream has ended both channels are closed: the event channel
+// first, then the error channel after the terminal error, if any, has been
+// received. So after ranging over the events, the terminal error is received
+// from the error channel, nil if the stream was stopped.
+// Connect returns a *Stream handle instead, which is easier to stop and wait for,
+// and StreamResults merges events and errors, in order, on a single channel.
+// opts override the configuration of the Client for this stream.
+func (c *Client) Stream(req *http.Request, opts ...StreamOption) (<-chan *Event, <-chan error) {
+	req = applyStreamOptions(req, opts)
+	if c.CoalesceStreams && coalescable(req) {
+		return c.streamShared(req)
+	}
+
+	eventch, errch, _ := c.startStream(req)
+	return eventch, errch
+}
+
+// startStream starts streaming in a goroutine. done is closed once the goroutine
+// has exited, after both the event and error channels have been closed.
+func (c *Client) startStream(req *http.Request) (chan *Event, chan error, <-chan struct{}) {
 	eventch := make(chan *Event)
+	errch := make(chan error)
 
+	stream := newActiveStream()
 	c.mutex.Lock()
-	c.currentlyStreaming[eventch] = make(chan bool)
+	if c.closed {
+		c.mutex.Unlock()
+		return closedStream()
+	}
+	c.streams[eventch] = stream
 	c.mutex.Unlock()
-
-	errch := make(chan error)
+	stopch, done := stream.stopch, stream.done
 
 	go func() {
-		var resp *http.Response
+		defer close(done)
 
-		defer c.closeRespAndCurrStreamCh(resp, eventch)
+		// registered first so the queue below is closed while stopch can
+		// still only be closed by StopStream
+		defer c.untrackStream(eventch, stream)
 
-		resp, err := c.HTTPClient.Do(req)
-		if err != nil {
-			errch <- err
-			return
+		// err is the error the stream ends with, reported once everything
+		// queued has been delivered
+		var err error
+		if c.Tracer != nil {
+			var ctx context.Context
+			var span traceSpan
+			ctx, span = c.startSpan(req.Context(), "sse.stream", req)
+			req = req.WithContext(ctx)
+			defer func() { span.end(err) }()
+		}
+		if c.OnClose != nil {
+			defer func() { c.OnClose(req, err) }()
+		}
+		// the event channel is closed first so consumers ranging over it are
+		// done, then the terminal error can be received from the error channel
+		defer func() {
+			close(eventch)
+			if isStopped(req, stopch) {
+				err = nil
+			}
+			if err != nil {
+				if c.OnError != nil {
+					c.OnError(req, err)
+				}
+				select {
+				case errch <- err:
+				case <-stopch:
+				case <-req.Context().Done():
+				}
+			}
+			close(errch)
+		}()
+		// a panic, e.g. in a hook, ends the stream with a *PanicError
+		defer recoverPanic(&err)
+
+		// once the stream is stopped nobody may be receiving anymore, so
+		// results are dropped rather than blocking forever
+		slow := c.newSlowConsumerDetector()
+		emit := func(r Result) {
+			if r.Err != nil {
+				select {
+				case errch <- r.Err:
+				case <-stopch:
+				case <-req.Context().Done():
+				}
+			} else {
+				start := time.Now()
+				delivered := slow.snapshot(r.Event)
+				select {
+				case eventch <- r.Event:
+					slow.observe(delivered, time.Since(start))
+				case <-stopch:
+				case <-req.Context().Done():
+				}
+			}
+		}
+		if bp := c.backpressure(req.Context()); bp.QueueSize > 0 {
+			queue := newDeliveryQueue(bp, c.isPriorityEvent, slow, func(event *Event) {
+				c.logger().DroppedEvent(req, event)
+				event.Release()
+			}, eventch, errch, stopch, req.Context().Done())
+			defer queue.close()
+			emit = queue.push
+		}
+		if c.OnError != nil {
+			deliver := emit
+			emit = func(r Result) {
+				if r.Err != nil {
+					c.OnError(req, r.Err)
+				}
+				deliver(r)
+			}
 		}
-		if resp.StatusCode != 200 {
-			errch <- errors.New("non-200 status code from stream")
-			return
+		if filter := eventFilterFromContext(req.Context()); filter != nil {
+			emit = filterEvents(emit, filter)
+		}
+		if middlewares := eventMiddlewareFromContext(req.Context()); len(middlewares) > 0 {
+			emit = transformEvents(emit, middlewares)
 		}
 
-		scanner := newEventScanner(resp.Body)
+		metadata := MetadataFromContext(req.Context())
+		tracker := stateTrackerFromContext(req.Context())
+		metrics := c.streamMetrics(req)
+		var state streamState
+		var loadErr error
+		if req, loadErr = c.loadEventID(req, &state); loadErr != nil {
+			// the stream starts from scratch instead
+			emit(Result{Err: loadErr})
+		}
+		state.endpoints = c.endpoints(req)
 
 		for {
-			eventBytes, err := scanner.scanEvent()
-			if err != nil {
-				// stream no longer sending data
-				if err == io.EOF {
-					errch <- ErrStreamIsClosed
+			connReq := c.resumeRequest(req, &state)
+			if state.reconnecting {
+				if connReq, err = rewindBody(connReq); err != nil {
 					return
 				}
-
-				errch <- err
+			}
+			c.reportEndpoint(connReq, &state)
+			connReq, span := c.traceConnect(connReq, &state)
+			err = c.readStream(connReq, metadata, metrics, emit, stopch, &state)
+			span.end(err)
+			if err != nil && err != errStreamStopped {
+				c.logger().Disconnected(connReq, err)
+			}
+			state.reconnecting = true
+			if err == errStreamStopped {
+				err = nil
 				return
 			}
-
-			// readEvent only returns an error if the message should be ignored
-			if event, err := readEvent(eventBytes); err == nil {
-				eventch <- event
+			if err == errReconnectRequested || err == errConnectionRotated {
+				// reconnected right away, even without Reconnect set
+				tracker.set(Reconnecting)
+				metrics.reconnecting(err)
+				continue
+			}
+			if ctrlErr, ok := err.(*controlError); ok {
+				ctrl := ctrlErr.ctrl
+				if c.OnControl != nil {
+					c.OnControl(ctrl)
+				}
+				req = applyControl(req, ctrl)
+				if ctrl.Directive != ControlBackoff {
+					state.offset = 0
+				}
+				if ctrl.Directive == ControlReset {
+					state.lastEventID = ""
+					if saveErr := c.saveEventID(&state); saveErr != nil {
+						emit(Result{Err: saveErr})
+					}
+				}
+				tracker.set(Reconnecting)
+				metrics.reconnecting(err)
+				if !c.waitToReconnect(req, stopch, Decision{Action: ActionRetryAfter, Delay: ctrl.Delay}, err) {
+					err = nil
+					return
+				}
+				continue
 			}
 
-			// user requested to stop the stream (non-blocking check)
-			select {
-			case <-c.currentlyStreaming[eventch]:
+			// err is the terminal error unless the stream reconnects
+			if !c.Reconnect {
 				return
 			}
+			decision := c.reconnectDecision(req.Context(), err, &state)
+			if decision.Action == ActionFatal {
+				return
+			}
+			// the error is only informational when reconnecting
+			emit(Result{Err: err})
+			req = c.failover(req, &state)
+			tracker.set(Reconnecting)
+			metrics.reconnecting(err)
+			if !c.waitToReconnect(req, stopch, decision, err) {
+				err = nil
+				return
+			}
+			// probing only picks the endpoint to reconnect to, a healthy
+			// endpoint that keeps dropping the stream still waits the delay
+			if c.HealthProber != nil {
+				healthy, ok := c.waitForHealthy(req, stopch, &state)
+				if !ok {
+					err = nil
+					return
+				}
+				if healthy != req {
+					// byte offsets don't carry over to another endpoint
+					state.offset = 0
+				}
+				req = healthy
+			}
 		}
 	}()
 
-	return eventch, errch
+	return eventch, errch, done
+}
+
+// streamState is what a stream keeps across reconnects
+type streamState struct {
+	// offset is the byte offset of the end of the last event read
+	offset int64
+	// retry is the reconnect delay last sent by the server in a retry field
+	retry time.Duration
+	// lastEventID is the ID of the last event received with one, or loaded
+	// from the EventIDStore
+	lastEventID string
+	// reconnecting is set once the first connection has ended
+	reconnecting bool
+	// attempts is the number of connection attempts that failed since the
+	// last connection was open
+	attempts int
+	// failedResp is the response of the last attempt if it was rejected
+	failedResp *http.Response
+	// storeKey is the key of the stream in the Client's EventIDStore, empty
+	// if its IDs aren't saved
+	storeKey string
+	// endpoints are the endpoints the stream fails over between and probes,
+	// see Failover
+	endpoints []string
+	// activeEndpoint is the URL of the last connection attempt, once reported
+	activeEndpoint string
+}
+
+// reconnectDecision decides what to do after a connection ended with err.
+// A retry is delayed by the server's Retry-After header, or else the
+// ReconnectPolicy, or else the server's retry field, or else ReconnectDelay.
+func (c *Client) reconnectDecision(ctx context.Context, err error, state *streamState) Decision {
+	state.attempts++
+	decision := c.classify(ctx, err)
+	if decision.Action != ActionRetry {
+		return decision
+	}
+
+	if delay, ok := retryAfter(err); ok {
+		return Decision{Action: ActionRetryAfter, Delay: delay}
+	}
+	if c.ReconnectPolicy != nil {
+		delay, retry := c.ReconnectPolicy.NextDelay(state.attempts, err, state.failedResp)
+		if !retry {
+			return Decision{Action: ActionFatal}
+		}
+		return Decision{Action: ActionRetryAfter, Delay: delay}
+	}
+	if state.retry > 0 {
+		return Decision{Action: ActionRetryAfter, Delay: state.retry}
+	}
+	return decision
+}
+
+// closedStream returns the channels of a stream that ended with
+// ErrClientClosed right away
+func closedStream() (chan *Event, chan error, <-chan struct{}) {
+	eventch := make(chan *Event)
+	close(eventch)
+	errch := make(chan error, 1)
+	errch <- ErrClientClosed
+	close(errch)
+	done := make(chan struct{})
+	close(done)
+	return eventch, errch, done
+}
+
+// Close stops every stream of the Client and waits for their goroutines to
+// exit, or for ctx to be done, in which case ctx's error is returned. Streams
+// started afterwards end with ErrClientClosed right away. Results not yet
+// received from the streams are dropped.
+func (c *Client) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	c.closed = true
+	streams := make([]*activeStream, 0, len(c.streams))
+	for _, stream := range c.streams {
+		streams = append(streams, stream)
+	}
+	c.mutex.Unlock()
+
+	for _, stream := range streams {
+		stream.stop()
+	}
+	for _, stream := range streams {
+		select {
+		case <-stream.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
 }
 
-// StopStream pass in the channel used for getting the events to stop the stream
+// StopStream pass in the channel used for getting the events to stop the stream.
+// The connection is closed right away, without waiting for the next event.
+// Results not yet received from the stream are dropped.
 func (c *Client) StopStream(ch chan *Event) {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	stream, ok := c.streams[ch]
+	delete(c.streams, ch)
+	c.mutex.Unlock()
 
-	if streamch, ok := c.currentlyStreaming[ch]; ok {
-		streamch <- true
+	if ok {
+		stream.stop()
 	}
 }
 
-// closeRespAndCurrStreamCh closes the response if possible and
-// closes/deletes the channel used for stopping the stream
-func (c *Client) closeRespAndCurrStreamCh(resp *http.Response, ch chan *Event) {
-	if resp != nil {
-		resp.Body.Close()
+// readStream connects and delivers events until the connection fails.
+// errStreamStopped is returned if the stream shouldn't be reconnected.
+// state is updated with every event read.
+func (c *Client) readStream(req *http.Request, metadata Metadata, metrics streamMetrics, emit func(Result), stopch <-chan struct{}, state *streamState) (err error) {
+	state.failedResp = nil
+	if err := checkStreaming(); err != nil {
+		return err
+	}
+
+	// the connection has its own context so stopping the stream, the idle
+	// timeout or Stream.Reconnect can abort it, even while a read is blocked
+	// on a quiet stream
+	connCtx, abort := context.WithCancel(req.Context())
+	defer abort()
+	reconnectRequested := make(chan struct{})
+	go func() {
+		select {
+		case <-stopch:
+			abort()
+		case <-reconnectSignalFromContext(req.Context()):
+			close(reconnectRequested)
+			abort()
+		case <-connCtx.Done():
+		}
+	}()
+	defer func() {
+		select {
+		case <-reconnectRequested:
+			if err != errStreamStopped {
+				err = errReconnectRequested
+			}
+		default:
+		}
+	}()
+
+	connReq, err := c.prepareRequest(req.WithContext(connCtx))
+	if err != nil {
+		return err
+	}
+	connectStart := time.Now()
+	connectDeadline := startDeadline(c.ConnectTimeout, abort)
+	resp, err := c.HTTPClient.Do(connReq)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	if connectDeadline.stop() && !isStopped(req, stopch) {
+		return ErrConnectTimeout
+	}
+	if err != nil {
+		if isStopped(req, stopch) {
+			return errStreamStopped
+		}
+		return err
+	}
+	trace := ContextStreamTrace(req.Context())
+	trace.gotResponse(resp)
+
+	resumedWithRange := c.ResumeFromOffset && c.OffsetHeader == "" && state.offset > 0
+	traceSpanFromContext(req.Context()).setAttributes(Attribute{Key: "http.response.status_code", Value: resp.StatusCode})
+	if resp.StatusCode != 200 && !(resumedWithRange && resp.StatusCode == http.StatusPartialContent) {
+		state.failedResp = resp
+		return newHTTPError(resp)
+	}
+	if err := c.ContentTypeCheck.checkContentType(resp); err != nil {
+		state.failedResp = resp
+		return err
+	}
+	state.attempts = 0
+	state.failedResp = nil
+	startOffset := state.offset
+	if resumedWithRange && resp.StatusCode != http.StatusPartialContent {
+		// the server ignored Range and is sending the stream from the start
+		startOffset = 0
+		state.offset = 0
+	}
+	stateTrackerFromContext(req.Context()).set(Open)
+	metrics.connected(time.Since(connectStart))
+	c.logger().Connected(req, resp)
+	if c.OnOpen != nil {
+		c.OnOpen(resp)
+	}
+	trace.connected(resp)
+
+	if c.HeartbeatInterval > 0 {
+		stopHeartbeats := make(chan struct{})
+		heartbeatsDone := make(chan struct{})
+		go func() {
+			defer close(heartbeatsDone)
+			sendHeartbeats(emit, c.HeartbeatInterval, metadata, stopHeartbeats)
+		}()
+		defer func() {
+			close(stopHeartbeats)
+			<-heartbeatsDone
+		}()
+	}
+
+	companion := c.startCompanion(connCtx)
+	defer companion.close()
+
+	var body io.Reader = metrics.body(resp.Body)
+	if c.IdleTimeout > 0 {
+		idle := newIdleReader(resp.Body, c.IdleTimeout, abort)
+		defer idle.stop()
+		body = idle
+	}
+	if tee := c.tee(req.Context()); tee != nil {
+		body = io.TeeReader(body, tee)
 	}
+	scanner := newEventScanner(body)
+	scanner.setBufferSizes(c.InitialBufferSize, c.MaxEventSize)
+	firstEventDeadline := startDeadline(c.FirstEventTimeout, abort)
+	defer firstEventDeadline.stop()
+	maxAge := startDeadline(c.MaxConnectionAge, abort)
+	defer maxAge.stop()
+	types := eventTypesFromContext(req.Context())
+
+	for {
+		eventBytes, err := scanner.scanEvent()
+		if err != nil && isStopped(req, stopch) {
+			return errStreamStopped
+		}
+		if err != nil && firstEventDeadline.hasExpired() {
+			return ErrFirstEventTimeout
+		}
+		if err != nil && maxAge.hasExpired() {
+			return errConnectionRotated
+		}
+		if err != nil {
+			// stream no longer sending data
+			if err == io.EOF {
+				return ErrStreamIsClosed
+			}
+
+			return err
+		}
+
+		state.offset = startOffset + scanner.consumed
+		trace.gotBlock(eventBytes)
+		if c.OnComment != nil {
+			comments(eventBytes, func(comment string) { c.OnComment(req, comment) })
+		}
+
+		var utf8Err error
+		if eventBytes, utf8Err = c.InvalidUTF8.apply(eventBytes); utf8Err != nil {
+			c.logger().ParseError(req, utf8Err)
+			emit(Result{Err: utf8Err})
+		} else if c.HeartbeatOnComment && isCommentBlock(eventBytes) {
+			emit(Result{Event: newHeartbeatEvent(metadata)})
+		} else if types != nil && !isCommentBlock(eventBytes) && !c.readsEvent(types, eventBytes) {
+			firstEventDeadline.stop()
+			if c.skipEvent(eventBytes, state) {
+				if err := c.saveEventID(state); err != nil {
+					emit(Result{Err: err})
+				}
+			}
+		} else if event, ok := c.parseEvent(eventBytes, state.lastEventID); ok {
+			if !isCommentBlock(eventBytes) {
+				firstEventDeadline.stop()
+			}
+			event.Metadata = metadata
+			if event.Type == "" {
+				event.Type = c.DefaultEventType
+			}
+			if event.Retry > 0 {
+				state.retry = event.Retry
+			}
+			if gap := c.sequenceGap(event, state.lastEventID); gap != nil {
+				emit(Result{Err: gap})
+			}
+			idChanged := event.LastEventID != ""
+			if idChanged {
+				state.lastEventID = event.LastEventID
+			} else if c.CarryLastEventID {
+				// an empty id field resets the last event ID
+				if value, found := findField(eventBytes, []byte(eventTypeID)); found && len(value) == 0 {
+					idChanged = state.lastEventID != ""
+					state.lastEventID = ""
+				}
+				event.LastEventID = state.lastEventID
+			}
+			// per the spec, events without data aren't dispatched, though
+			// their other fields still apply
+			if len(event.Data) > 0 || c.DispatchEmptyEvents {
+				if err := c.handleEvent(req, event, eventBytes, metrics, emit, companion); err != nil {
+					return err
+				}
+			} else {
+				event.Release()
+			}
+			if idChanged {
+				if err := c.saveEventID(state); err != nil {
+					emit(Result{Err: err})
+				}
+			}
+		}
+
+		if isStopped(req, stopch) {
+			return errStreamStopped
+		}
+	}
+}
+
+// isStopped reports whether the user stopped the stream, with StopStream or
+// the request's context
+func isStopped(req *http.Request, stopch <-chan struct{}) bool {
+	select {
+	case <-stopch:
+		return true
+	case <-req.Context().Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// handleEvent processes an event read from the stream and delivers it.
+// A non-nil error ends the connection.
+func (c *Client) handleEvent(req *http.Request, event *Event, eventBytes []byte, metrics streamMetrics, emit func(Result), companion *companionRunner) error {
+	if err := c.PayloadDecoding.decodePayload(event, eventBytes, c.MaxEventSize); err != nil {
+		c.logger().ParseError(req, err)
+		emit(Result{Err: err})
+		return nil
+	}
+	c.setTimestamp(event, eventBytes, metrics)
+
+	switch {
+	case c.ControlEventType != "" && event.Type == c.ControlEventType:
+		ctrl, err := parseControl(event, req.URL)
+		if err != nil {
+			err := &InvalidControlError{Event: event, Err: err}
+			c.logger().ParseError(req, err)
+			emit(Result{Err: err})
+			return nil
+		}
+		return &controlError{ctrl}
+	case c.isErrorEvent(event):
+		emit(Result{Err: newEventError(event)})
+		if c.StopOnErrorEvent {
+			return errStreamStopped
+		}
+	default:
+		metrics.eventReceived(event)
+		if c.TraceEvents {
+			traceSpanFromContext(req.Context()).addEvent("sse.event",
+				Attribute{Key: "sse.event.id", Value: event.LastEventID},
+				Attribute{Key: "sse.event.type", Value: event.Type},
+			)
+		}
+		ContextStreamTrace(req.Context()).dispatchedEvent(event)
+		// nothing of a pooled event may be read once it is handed over
+		ack := companion.acknowledgement(event)
+		emit(Result{Event: event})
+		companion.ack(ack)
+	}
+	return nil
+}
+
+// resumeRequest returns the request to connect with, resuming from the last
+// event ID and offset when reconnecting
+func (c *Client) resumeRequest(req *http.Request, state *streamState) *http.Request {
+	if !state.reconnecting {
+		return req
+	}
+
+	resumeID := !c.DisableLastEventID && (state.lastEventID != "" || c.LastEventID != nil)
+	resumeOffset := c.ResumeFromOffset && state.offset > 0
+	if !resumeID && !resumeOffset {
+		return req
+	}
+
+	req = cloneRequest(req)
+	if resumeID {
+		lastEventID := state.lastEventID
+		if c.LastEventID != nil {
+			lastEventID = c.LastEventID(lastEventID)
+		}
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		} else {
+			req.Header.Del("Last-Event-ID")
+		}
+	}
+	if resumeOffset {
+		if c.OffsetHeader != "" {
+			req.Header.Set(c.OffsetHeader, strconv.FormatInt(state.offset, 10))
+		} else {
+			req.Header.Set("Range", "bytes="+strconv.FormatInt(state.offset, 10)+"-")
+		}
+	}
+	return req
+}
+
+// prepareRequest sets the default headers and credentials on the request of
+// a connection attempt and calls the BeforeConnect hook
+func (c *Client) prepareRequest(req *http.Request) (*http.Request, error) {
+	if len(c.Header) == 0 && c.Authenticate == nil && c.BeforeConnect == nil {
+		return req, nil
+	}
+
+	req = cloneRequest(req)
+	for key, values := range c.Header {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+	if c.Authenticate != nil {
+		if err := c.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+	if c.BeforeConnect != nil {
+		if err := c.BeforeConnect(req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// rewindBody returns req with a fresh copy of its body, the previous one
+// having been consumed by the last connection
+func rewindBody(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+// waitToReconnect waits for the reconnect delay and the retry budget.
+// It returns false if the stream was stopped while waiting.
+func (c *Client) waitToReconnect(req *http.Request, stopch <-chan struct{}, decision Decision, err error) bool {
+	delay := c.ReconnectDelay
+	if decision.Action == ActionRetryAfter {
+		delay = decision.Delay
+	} else if delay <= 0 {
+		delay = DefaultReconnectDelay
+	}
+	if c.RetryBudget != nil {
+		delay += c.RetryBudget.reserve()
+	}
+	c.logger().Reconnecting(req, delay)
+	ContextStreamTrace(req.Context()).willReconnect(err, delay)
+	stateTrackerFromContext(req.Context()).setBackoff(delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-reconnectSignalFromContext(req.Context()):
+		return true
+	case <-stopch:
+		return false
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// untrackStream stops tracking a stream that has ended, closing its stopch
+func (c *Client) untrackStream(ch chan *Event, stream *activeStream) {
+	stream.stop()
 
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-
-	if streamch, ok := c.currentlyStreaming[ch]; ok {
-		close(streamch)
-		delete(c.currentlyStreaming, ch)
+	if c.streams[ch] == stream {
+		delete(c.streams, ch)
 	}
 }
@@ -0,0 +1,687 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_resumeRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/events", nil)
+	ok(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	c := &Client{}
+	equals(t, req, c.resumeRequest(req, &streamState{offset: 100, lastEventID: "42"}))
+	equals(t, req, c.resumeRequest(req, &streamState{reconnecting: true}))
+	equals(t, "42", c.resumeRequest(req, &streamState{reconnecting: true, lastEventID: "42"}).Header.Get("Last-Event-ID"))
+	equals(t, "1", req.Header.Get("Last-Event-ID"))
+
+	c.DisableLastEventID = true
+	equals(t, req, c.resumeRequest(req, &streamState{reconnecting: true, lastEventID: "42"}))
+
+	c.DisableLastEventID = false
+	c.LastEventID = func(received string) string { return "checkpoint-" + received }
+	equals(t, "checkpoint-42", c.resumeRequest(req, &streamState{reconnecting: true, lastEventID: "42"}).Header.Get("Last-Event-ID"))
+	c.LastEventID = func(received string) string { return "" }
+	equals(t, "", c.resumeRequest(req, &streamState{reconnecting: true, lastEventID: "42"}).Header.Get("Last-Event-ID"))
+
+	c.LastEventID = nil
+	c.ResumeFromOffset = true
+	equals(t, req, c.resumeRequest(req, &streamState{reconnecting: true}))
+	equals(t, "bytes=100-", c.resumeRequest(req, &streamState{reconnecting: true, offset: 100}).Header.Get("Range"))
+	equals(t, "", req.Header.Get("Range"))
+
+	c.OffsetHeader = "X-Stream-Offset"
+	resumed := c.resumeRequest(req, &streamState{reconnecting: true, offset: 100})
+	equals(t, "100", resumed.Header.Get("X-Stream-Offset"))
+	equals(t, "", resumed.Header.Get("Range"))
+}
+
+func TestClient_ResumeFromOffsetIgnored(t *testing.T) {
+	const stream = "data: a\n\n"
+	ranges := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case ranges <- r.Header.Get("Range"):
+		default:
+		}
+		// ignore Range and always send the whole stream
+		w.Write([]byte(stream))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.Reconnect = true
+	c.ReconnectDelay = time.Millisecond
+	c.ResumeFromOffset = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	go func() {
+		for {
+			select {
+			case <-eventch:
+			case <-errch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	equals(t, "", <-ranges)
+	// the offset of a stream sent from the start again doesn't add up
+	resumeFrom := "bytes=" + strconv.Itoa(len(stream)) + "-"
+	equals(t, resumeFrom, <-ranges)
+	equals(t, resumeFrom, <-ranges)
+}
+
+func TestClient_Tee(t *testing.T) {
+	const stream = "data: hello\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stream))
+	}))
+	defer server.Close()
+
+	var tee bytes.Buffer
+	c := NewClient(server.Client())
+	c.Tee = &tee
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, _ := c.Stream(req)
+	event := <-eventch
+	equals(t, "hello", string(event.Data))
+	equals(t, stream, tee.String())
+}
+
+func TestClient_WithTee(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: " + r.URL.Path + "\n\n"))
+	}))
+	defer server.Close()
+
+	var shared bytes.Buffer
+	c := NewClient(server.Client())
+	c.Tee = &shared
+
+	tees := map[string]*bytes.Buffer{"/a": {}, "/b": {}}
+	for path, tee := range tees {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		ok(t, err)
+		eventch, _ := c.Stream(req.WithContext(WithTee(context.Background(), tee)))
+		equals(t, path, string((<-eventch).Data))
+	}
+
+	for path, tee := range tees {
+		equals(t, "data: "+path+"\n\n", tee.String())
+	}
+	equals(t, "", shared.String())
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Write([]byte("data: a\n\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.Reconnect = true
+	c.ReconnectDelay = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	var data []string
+	for len(data) < 4 {
+		select {
+		case event := <-eventch:
+			if len(event.Data) > 0 {
+				data = append(data, string(event.Data))
+			}
+		case err := <-errch:
+			equals(t, ErrStreamIsClosed, err)
+		}
+	}
+	equals(t, []string{"a", "b", "a", "b"}, data)
+	assert(t, atomic.LoadInt32(&connections) >= 2, "expected a reconnect")
+}
+
+func TestClient_LastEventIDOnReconnect(t *testing.T) {
+	ids := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case ids <- r.Header.Get("Last-Event-ID"):
+		default:
+		}
+		w.Write([]byte("id: 7\ndata: a\n\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.Reconnect = true
+	c.ReconnectDelay = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	go func() {
+		for {
+			select {
+			case <-eventch:
+			case <-errch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	equals(t, "", <-ids)
+	equals(t, "7", <-ids)
+}
+
+func TestClient_ServerRetry(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Write([]byte("retry: 1\ndata: a\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.Reconnect = true
+	// only the server's retry lets the stream reconnect within the test
+	c.ReconnectDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	timeout := time.After(5 * time.Second)
+	for atomic.LoadInt32(&connections) < 2 {
+		select {
+		case <-eventch:
+		case <-errch:
+		case <-timeout:
+			t.Fatal("didn't reconnect after the server's retry")
+		}
+	}
+}
+
+func TestClient_LifecycleCallbacks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Stream", "prices")
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	var calls []string
+	c := NewClient(server.Client())
+	c.OnOpen = func(resp *http.Response) {
+		calls = append(calls, "open "+resp.Header.Get("X-Stream"))
+	}
+	c.OnError = func(req *http.Request, err error) {
+		calls = append(calls, "error "+err.Error())
+	}
+	closed := make(chan error, 1)
+	c.OnClose = func(req *http.Request, err error) {
+		closed <- err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	for range c.StreamResults(req) {
+	}
+
+	equals(t, ErrStreamIsClosed, <-closed)
+	equals(t, []string{"open prices", "error " + ErrStreamIsClosed.Error()}, calls)
+}
+
+func TestClient_OnCloseStopped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	closed := make(chan error, 1)
+	c := NewClient(server.Client())
+	c.OnClose = func(req *http.Request, err error) {
+		closed <- err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	stream := c.Connect(req)
+	<-stream.Events()
+	ok(t, stream.Close())
+	ok(t, <-closed)
+}
+
+func TestClient_StopStreamConcurrently(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	// streams end on their own while being stopped, which the race detector
+	// checks the bookkeeping of
+	c := NewClient(server.Client())
+	var streams sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		ok(t, err)
+		eventch, errch, done := c.startStream(req)
+		streams.Add(1)
+		go func() {
+			defer streams.Done()
+			for range collect(eventch, errch) {
+			}
+			<-done
+		}()
+		go c.StopStream(eventch)
+		go c.StopStream(eventch)
+	}
+	streams.Wait()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	equals(t, 0, len(c.streams))
+}
+
+func TestClient_StopStreamQuiet(t *testing.T) {
+	disconnected := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		// nothing else is ever sent
+		<-r.Context().Done()
+		close(disconnected)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, errch, done := c.startStream(req)
+	equals(t, "a", string((<-eventch).Data))
+	c.StopStream(eventch)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the stream didn't stop before the next event")
+	}
+	_, open := <-errch
+	assert(t, !open, "no error should be reported after stopping")
+	<-disconnected
+}
+
+func TestClient_StreamClosesChannels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	eventch, errch := NewClient(server.Client()).Stream(req)
+
+	var data []string
+	for event := range eventch {
+		data = append(data, string(event.Data))
+	}
+	equals(t, []string{"a", "b"}, data)
+	equals(t, ErrStreamIsClosed, <-errch)
+	_, open := <-errch
+	assert(t, !open, "errors should be closed after the terminal error")
+}
+
+func TestClient_HeaderAndBeforeConnect(t *testing.T) {
+	received := make(chan http.Header, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	var attempts int32
+	c := NewClient(server.Client(),
+		WithReconnect(time.Millisecond),
+		WithHeader("X-Client", "sse"),
+		WithHeader("X-Override", "default"),
+		WithBeforeConnect(func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+strconv.Itoa(int(atomic.AddInt32(&attempts, 1))))
+			return nil
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	req.Header.Set("X-Override", "request")
+	results := collect(c.Stream(req.WithContext(ctx)))
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		header := <-received
+		equals(t, "sse", header.Get("X-Client"))
+		equals(t, "request", header.Get("X-Override"))
+		equals(t, "Bearer "+strconv.Itoa(attempt), header.Get("Authorization"))
+		<-results
+		<-results
+	}
+	equals(t, "", req.Header.Get("Authorization"))
+}
+
+func TestClient_BeforeConnectError(t *testing.T) {
+	c := NewClient(http.DefaultClient, WithBeforeConnect(func(req *http.Request) error {
+		return errors.New("no token")
+	}))
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:1", nil)
+	ok(t, err)
+	_, errch := c.Stream(req)
+	equals(t, "no token", (<-errch).Error())
+}
+
+func TestClient_OnComment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(": keep-alive\n\n:no space\ndata: hello\n: trailing\n\n"))
+	}))
+	defer server.Close()
+
+	var comments []string
+	c := NewClient(server.Client(), WithCommentHandler(func(req *http.Request, comment string) {
+		comments = append(comments, comment)
+	}))
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var data []string
+	for r := range collect(c.Stream(req)) {
+		if r.Event != nil && len(r.Event.Data) > 0 {
+			data = append(data, string(r.Event.Data))
+		}
+	}
+	equals(t, []string{"hello"}, data)
+	equals(t, []string{"keep-alive", "no space", "trailing"}, comments)
+}
+
+func TestClient_DefaultEventType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: plain\n\nevent: update\ndata: typed\n\n"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		testname    string
+		defaultType string
+		expected    []string
+	}{
+		{"left empty", "", []string{"", "update"}},
+		{"message", MessageEventType, []string{"message", "update"}},
+	}
+
+	for _, test := range tests {
+		c := NewClient(server.Client(), WithDefaultEventType(test.defaultType))
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		ok(t, err)
+
+		var types []string
+		for r := range collect(c.Stream(req)) {
+			if r.Event != nil {
+				types = append(types, r.Event.Type)
+			}
+		}
+		assert(t, reflect.DeepEqual(test.expected, types), "%s: expected %q, got %q", test.testname, test.expected, types)
+	}
+}
+
+func TestClient_EmptyEvents(t *testing.T) {
+	lastEventIDs := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Write([]byte(": keep-alive\n\ndata: hello\n\nid: 7\n\ndata:\n\n"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		testname string
+		dispatch bool
+		expected []string
+	}{
+		{"dropped per spec", false, []string{"hello"}},
+		{"dispatched", true, []string{"", "hello", "", ""}},
+	}
+
+	for _, test := range tests {
+		c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+		c.DispatchEmptyEvents = test.dispatch
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		ok(t, err)
+
+		var data []string
+		for r := range collect(c.Stream(req)) {
+			if r.Err != nil {
+				// the id of the event without data still applies
+				equals(t, "", <-lastEventIDs)
+				equals(t, "7", <-lastEventIDs)
+				cancel()
+			} else if r.Event != nil && ctx.Err() == nil {
+				data = append(data, string(r.Event.Data))
+			}
+		}
+		cancel()
+		assert(t, reflect.DeepEqual(test.expected, data), "%s: expected %q, got %q", test.testname, test.expected, data)
+		for len(lastEventIDs) > 0 {
+			<-lastEventIDs
+		}
+	}
+}
+
+func TestClient_CarryLastEventID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\nid: 1\ndata: b\n\ndata: c\n\nid\ndata: d\n\ndata: e\n\nid: 2\ndata: f\n\n"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		testname string
+		carry    bool
+		expected []string
+	}{
+		{"own IDs only", false, []string{"", "1", "", "", "", "2"}},
+		{"carried forward", true, []string{"", "1", "1", "", "", "2"}},
+	}
+
+	for _, test := range tests {
+		c := NewClient(server.Client())
+		c.CarryLastEventID = test.carry
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		ok(t, err)
+
+		var ids []string
+		for r := range collect(c.Stream(req)) {
+			if r.Event != nil {
+				ids = append(ids, r.Event.LastEventID)
+			}
+		}
+		assert(t, reflect.DeepEqual(test.expected, ids), "%s: expected %q, got %q", test.testname, test.expected, ids)
+	}
+}
+
+func TestClient_InvalidUTF8(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("\xEF\xBB\xBFdata: caf\xE9\n\ndata: ok\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithUTF8Policy(UTF8Error))
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var results []string
+	for r := range collect(c.Stream(req)) {
+		if r.Err != nil {
+			results = append(results, r.Err.Error())
+		} else {
+			results = append(results, string(r.Event.Data))
+		}
+	}
+	equals(t, []string{ErrInvalidUTF8.Error(), "ok", ErrStreamIsClosed.Error()}, results)
+}
+
+func TestClient_MaxEventSize(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Write([]byte("data: small\n\ndata: " + strings.Repeat("x", 2048) + "\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+	c.MaxEventSize = 1024
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var results []string
+	for r := range collect(c.Stream(req)) {
+		if r.Err != nil {
+			results = append(results, r.Err.Error())
+		} else {
+			results = append(results, string(r.Event.Data))
+		}
+	}
+	// reconnecting would only get the event again
+	equals(t, []string{"small", ErrEventTooLarge.Error()}, results)
+	equals(t, int32(1), atomic.LoadInt32(&connections))
+}
+
+func TestClient_EventDataOwned(t *testing.T) {
+	var stream strings.Builder
+	for i := 0; i < 100; i++ {
+		stream.WriteString("data: event " + strconv.Itoa(i) + "\n\n")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stream.String()))
+	}))
+	defer server.Close()
+
+	// a small buffer is reused for every event, which events buffered by the
+	// consumer must not see
+	c := NewClient(server.Client())
+	c.InitialBufferSize = 16
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var events []*Event
+	for r := range collect(c.Stream(req)) {
+		if r.Event != nil {
+			events = append(events, r.Event)
+		}
+	}
+	equals(t, 100, len(events))
+	for i, event := range events {
+		equals(t, "event "+strconv.Itoa(i), string(event.Data))
+	}
+}
+
+func TestClient_Panic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(": boom\n\n"))
+	}))
+	defer server.Close()
+
+	closedWith := make(chan error, 1)
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond), WithCommentHandler(func(req *http.Request, comment string) {
+		panic(comment)
+	}))
+	c.OnClose = func(req *http.Request, err error) { closedWith <- err }
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var errs []error
+	for r := range collect(c.Stream(req)) {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	equals(t, 1, len(errs))
+	var panicErr *PanicError
+	assert(t, errors.As(errs[0], &panicErr), "expected a *PanicError, got %T", errs[0])
+	equals(t, "boom", panicErr.Value)
+	assert(t, strings.Contains(string(panicErr.Stack), "TestClient_Panic"), "expected the stack of the panic, got %s", panicErr.Stack)
+	equals(t, errs[0], <-closedWith)
+}
+
+func TestClient_Close(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, errch := c.Stream(req)
+	equals(t, "a", string((<-eventch).Data))
+	results := collect(eventch, errch)
+	handle := c.Connect(req)
+	<-handle.Events()
+	// Close also waits for shared streams, whose subscribers' channels are
+	// left open once stopped
+	c.CoalesceStreams = true
+	for i := 0; i < 2; i++ {
+		c.Stream(req)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ok(t, c.Close(ctx))
+	for r := range results {
+		equals(t, Result{}, r)
+	}
+	<-handle.Done()
+	ok(t, handle.Err())
+
+	// no stream starts once closed
+	var errs []error
+	for r := range collect(c.Stream(req)) {
+		errs = append(errs, r.Err)
+	}
+	equals(t, []error{ErrClientClosed}, errs)
+	c.CoalesceStreams = false
+	for r := range collect(c.Stream(req)) {
+		errs = append(errs, r.Err)
+	}
+	equals(t, []error{ErrClientClosed, ErrClientClosed}, errs)
+}
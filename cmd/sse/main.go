@@ -0,0 +1,132 @@
+// Command sse tails a server-sent events stream, printing its events as they
+// are received and reconnecting whenever the connection is lost.
+//
+//	go install github.com/mellena1/sse-client-go/cmd/sse@latest
+//	sse --header "Authorization: Bearer $TOKEN" --json https://example.com/events
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// headers is a repeatable flag of "Key: Value" headers
+type headers http.Header
+
+func (h headers) String() string {
+	var pairs []string
+	for key, values := range h {
+		for _, value := range values {
+			pairs = append(pairs, key+": "+value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (h headers) Set(s string) error {
+	key, value, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("header %q isn't of the form \"Key: Value\"", s)
+	}
+	http.Header(h).Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	return nil
+}
+
+func main() {
+	header := headers{}
+	flag.Var(header, "header", "header sent with every request, as \"Key: Value\" (repeatable)")
+	lastEventID := flag.String("last-event-id", "", "Last-Event-ID to resume the stream from")
+	retry := flag.Duration("retry", sse.DefaultReconnectDelay, "delay before reconnecting, unless the server sets one")
+	jsonLines := flag.Bool("json", false, "print events as JSON lines instead of the SSE wire format")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: sse [flags] URL\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := sse.NewClient(http.DefaultClient, sse.WithReconnect(*retry))
+	client.Header = http.Header(header)
+	var opts []sse.StreamOption
+	if *lastEventID != "" {
+		opts = append(opts, sse.StreamHeader("Last-Event-ID", *lastEventID))
+	}
+
+	if err := tail(ctx, client, flag.Arg(0), newPrinter(os.Stdout, *jsonLines), opts...); err != nil {
+		fmt.Fprintln(os.Stderr, "sse:", err)
+		os.Exit(1)
+	}
+}
+
+// tail prints the events of the stream at url until it ends or ctx is done.
+// Errors the stream recovers from are reported on stderr, the terminal error
+// is returned.
+func tail(ctx context.Context, client *sse.Client, url string, print func(*sse.Event) error, opts ...sse.StreamOption) error {
+	eventch, errch := client.Subscribe(ctx, url, opts...)
+	var last error
+	for eventch != nil || errch != nil {
+		select {
+		case event, open := <-eventch:
+			if !open {
+				eventch = nil
+				continue
+			}
+			if err := print(event); err != nil {
+				return err
+			}
+		case err, open := <-errch:
+			if !open {
+				errch = nil
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "sse:", err)
+			last = err
+		}
+	}
+	if ctx.Err() != nil || errors.Is(last, sse.ErrStreamIsClosed) {
+		return nil
+	}
+	return last
+}
+
+// jsonEvent is an event printed as a JSON line
+type jsonEvent struct {
+	ID    string `json:"id,omitempty"`
+	Type  string `json:"event,omitempty"`
+	Data  string `json:"data"`
+	Retry int64  `json:"retry,omitempty"`
+}
+
+// newPrinter returns a function printing events to w, as JSON lines or in the
+// SSE wire format
+func newPrinter(w io.Writer, jsonLines bool) func(*sse.Event) error {
+	if jsonLines {
+		encoder := json.NewEncoder(w)
+		return func(event *sse.Event) error {
+			return encoder.Encode(jsonEvent{
+				ID:    event.LastEventID,
+				Type:  event.Type,
+				Data:  string(event.Data),
+				Retry: int64(event.Retry / time.Millisecond),
+			})
+		}
+	}
+	return sse.NewEncoder(w).Encode
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+	"github.com/mellena1/sse-client-go/ssetest"
+)
+
+func TestHeaders(t *testing.T) {
+	h := headers{}
+	ok(t, h.Set("Authorization: Bearer abc"))
+	ok(t, h.Set("X-Trace:1"))
+	equals(t, headers{"Authorization": {"Bearer abc"}, "X-Trace": {"1"}}, h)
+	assert(t, h.Set("no colon") != nil, "header without a colon accepted")
+}
+
+func TestPrinter(t *testing.T) {
+	event := &sse.Event{LastEventID: "7", Type: "tick", Data: []byte("a\nb"), Retry: time.Second}
+
+	tests := []struct {
+		testname  string
+		jsonLines bool
+		expected  string
+	}{
+		{"wire format", false, "id: 7\nevent: tick\nretry: 1000\ndata: a\ndata: b\n\n"},
+		{"json lines", true, `{"id":"7","event":"tick","data":"a\nb","retry":1000}` + "\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		ok(t, newPrinter(&out, test.jsonLines)(event))
+		equals(t, test.expected, out.String())
+	}
+}
+
+func TestTail(t *testing.T) {
+	srv := ssetest.NewServer(
+		ssetest.Comment("keep-alive"),
+		ssetest.Send(&sse.Event{LastEventID: "1", Data: []byte("one")}),
+		ssetest.Drop(),
+	)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := sse.NewClient(http.DefaultClient, sse.WithReconnect(time.Millisecond))
+
+	var printed []string
+	print := func(event *sse.Event) error {
+		printed = append(printed, event.LastEventID+"="+string(event.Data))
+		if len(printed) == 2 {
+			cancel()
+		}
+		return nil
+	}
+	ok(t, tail(ctx, client, srv.URL, print, sse.StreamHeader("Last-Event-ID", "0")))
+
+	equals(t, []string{"1=one", "1=one"}, printed)
+	equals(t, []string{"0", "1"}, srv.LastEventIDs()[:2])
+}
@@ -0,0 +1,274 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// sharedStream is an upstream stream shared by every subscriber of the same request.
+// It lives as long as it has subscribers, independently of their requests' contexts.
+type sharedStream struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex       sync.Mutex
+	subscribers map[chan *Event]*subscriber
+}
+
+// subscriber is a downstream consumer of a shared stream
+type subscriber struct {
+	eventch  chan *Event
+	errch    chan error
+	gone     chan struct{}
+	metadata Metadata
+	// eventsClosed and errorsClosed are set once the channels are closed,
+	// they are guarded by the shared stream's mutex
+	eventsClosed bool
+	errorsClosed bool
+}
+
+// streamSettingKeys are the context keys of the per-stream settings, see
+// StreamOption, which a shared upstream can't apply for a single subscriber
+var streamSettingKeys = []interface{}{
+	backpressureKey{},
+	eventIDKey{},
+	eventTypesKey{},
+	eventFilterKey{},
+	eventMiddlewareKey{},
+	metricsLabelKey{},
+	statusPolicyKey{},
+	teeKey{},
+	streamTraceKey{},
+}
+
+// coalescable reports whether the stream of req can share its upstream: it
+// has no body and no per-stream setting but its metadata, which every
+// subscriber gets on its own events
+func coalescable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	for _, key := range streamSettingKeys {
+		if req.Context().Value(key) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// streamKey identifies requests that can share a connection
+func streamKey(req *http.Request) string {
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL.String())
+	for _, k := range keys {
+		key.WriteString("\n" + k + ": " + strings.Join(req.Header[k], ", "))
+	}
+	return key.String()
+}
+
+// streamShared subscribes to the shared stream of req, starting it if needed
+func (c *Client) streamShared(req *http.Request) (<-chan *Event, <-chan error) {
+	sub := &subscriber{
+		eventch:  make(chan *Event),
+		errch:    make(chan error),
+		gone:     make(chan struct{}),
+		metadata: MetadataFromContext(req.Context()),
+	}
+	stream := newActiveStream()
+	key := streamKey(req)
+
+	var upstream *http.Request
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		eventch, errch, _ := closedStream()
+		return eventch, errch
+	}
+	c.streams[sub.eventch] = stream
+	shared, ok := c.sharedStreams[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		shared = &sharedStream{
+			cancel:      cancel,
+			done:        make(chan struct{}),
+			subscribers: make(map[chan *Event]*subscriber),
+		}
+		c.sharedStreams[key] = shared
+		upstream = req.WithContext(ctx)
+	}
+	shared.mutex.Lock()
+	shared.subscribers[sub.eventch] = sub
+	shared.mutex.Unlock()
+	c.mutex.Unlock()
+
+	// startStream takes the mutex, so the upstream is started once it is released
+	if upstream != nil {
+		c.startSharedStream(shared, upstream)
+	}
+
+	go func() {
+		defer close(stream.done)
+		select {
+		case <-stream.stopch:
+		case <-req.Context().Done():
+		case <-shared.done:
+			// subscribers joining as the upstream ended weren't closed by it
+			shared.mutex.Lock()
+			sub.close()
+			shared.mutex.Unlock()
+		}
+		close(sub.gone)
+		c.untrackStream(sub.eventch, stream)
+		c.unsubscribe(key, shared, sub)
+	}()
+
+	return sub.eventch, sub.errch
+}
+
+// unsubscribe removes sub from a shared stream, stopping the stream once it has no subscribers
+func (c *Client) unsubscribe(key string, shared *sharedStream, sub *subscriber) {
+	// the client mutex is held so no subscriber can join a stream being stopped
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	shared.mutex.Lock()
+	delete(shared.subscribers, sub.eventch)
+	last := len(shared.subscribers) == 0
+	shared.mutex.Unlock()
+
+	if last {
+		if c.sharedStreams[key] == shared {
+			delete(c.sharedStreams, key)
+		}
+		shared.cancel()
+	}
+}
+
+// startSharedStream starts the upstream of a shared stream and fans its events out
+func (c *Client) startSharedStream(shared *sharedStream, req *http.Request) {
+	eventch, errch, done := c.startStream(req)
+
+	go func() {
+		defer close(shared.done)
+		for {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					shared.closeEvents()
+					continue
+				}
+				shared.sendEvent(event)
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				// the terminal error is sent once the events are closed, which
+				// subscribers must see first to stop ranging over them
+				var next *Event
+				if eventch != nil {
+					select {
+					case event, open := <-eventch:
+						if open {
+							next = event
+						} else {
+							eventch = nil
+							shared.closeEvents()
+						}
+					default:
+					}
+				}
+				shared.sendError(err)
+				if next != nil {
+					shared.sendEvent(next)
+				}
+			case <-done:
+				shared.mutex.Lock()
+				for _, sub := range shared.subscribers {
+					sub.close()
+				}
+				shared.mutex.Unlock()
+				return
+			}
+		}
+	}()
+}
+
+// sendEvent fans an event out to every subscriber
+func (s *sharedStream) sendEvent(event *Event) {
+	for _, sub := range s.snapshot() {
+		// every subscriber gets its own copy carrying its own metadata
+		subEvent := *event
+		subEvent.Metadata = sub.metadata
+		// their Data is shared, so the copies can't go back to the pool
+		subEvent.pooled = false
+		select {
+		case sub.eventch <- &subEvent:
+		case <-sub.gone:
+		}
+	}
+}
+
+// sendError fans an error out to every subscriber
+func (s *sharedStream) sendError(err error) {
+	for _, sub := range s.snapshot() {
+		select {
+		case sub.errch <- err:
+		case <-sub.gone:
+		}
+	}
+}
+
+// closeEvents closes the event channels of the subscribers once the upstream's is closed
+func (s *sharedStream) closeEvents() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, sub := range s.subscribers {
+		if !sub.eventsClosed {
+			sub.eventsClosed = true
+			close(sub.eventch)
+		}
+	}
+}
+
+// close closes the channels of a subscriber that aren't closed yet, it must
+// be called with the shared stream's mutex held once the upstream has ended
+func (sub *subscriber) close() {
+	if !sub.eventsClosed {
+		sub.eventsClosed = true
+		close(sub.eventch)
+	}
+	if !sub.errorsClosed {
+		sub.errorsClosed = true
+		close(sub.errch)
+	}
+}
+
+// snapshot returns the current subscribers
+func (s *sharedStream) snapshot() []*subscriber {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subs := make([]*subscriber, 0, len(s.subscribers))
+	for _, sub := range s.subscribers {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// subscriberCount returns the number of subscribers
+func (s *sharedStream) subscriberCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.subscribers)
+}
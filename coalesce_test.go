@@ -0,0 +1,161 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_CoalesceStreams(t *testing.T) {
+	var connections int32
+	send := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		<-send
+		w.Write([]byte("data: shared\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := NewClient(server.Client())
+	c.CoalesceStreams = true
+
+	ctx1, cancel1 := context.WithCancel(WithMetadata(context.Background(), Metadata{"sub": "1"}))
+	req1, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	req2, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	req2 = req2.WithContext(WithMetadata(context.Background(), Metadata{"sub": "2"}))
+
+	eventch1, _ := c.Stream(req1.WithContext(ctx1))
+	eventch2, _ := c.Stream(req2)
+
+	// the server only sends the event once both subscribers are registered
+	c.mutex.Lock()
+	shared := c.sharedStreams[streamKey(req1)]
+	c.mutex.Unlock()
+	for shared.subscriberCount() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(send)
+
+	// events are fanned out to one subscriber at a time, in no particular order
+	var event1, event2 *Event
+	for event1 == nil || event2 == nil {
+		select {
+		case event1 = <-eventch1:
+		case event2 = <-eventch2:
+		}
+	}
+	equals(t, "shared", string(event1.Data))
+	equals(t, "shared", string(event2.Data))
+	equals(t, Metadata{"sub": "1"}, event1.Metadata)
+	equals(t, Metadata{"sub": "2"}, event2.Metadata)
+	equals(t, int32(1), atomic.LoadInt32(&connections))
+
+	// cancelling the first subscriber doesn't stop the stream of the second
+	cancel1()
+	for shared.subscriberCount() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-shared.done:
+		t.Fatal("shared stream stopped while it still has a subscriber")
+	default:
+	}
+}
+
+func Test_streamKey(t *testing.T) {
+	req1, err := http.NewRequest(http.MethodGet, "http://example.com/events", nil)
+	ok(t, err)
+	req1.Header.Set("Authorization", "Bearer a")
+	req2, err := http.NewRequest(http.MethodGet, "http://example.com/events", nil)
+	ok(t, err)
+	req2.Header.Set("Authorization", "Bearer b")
+
+	assert(t, streamKey(req1) != streamKey(req2), "requests with different headers shouldn't share a stream")
+	req2.Header.Set("Authorization", "Bearer a")
+	equals(t, streamKey(req1), streamKey(req2))
+}
+
+func TestClient_CoalesceStreamsEnd(t *testing.T) {
+	send := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-send
+		w.Write([]byte("data: shared\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.CoalesceStreams = true
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	eventch1, errch1 := c.Stream(req)
+	eventch2, errch2 := c.Stream(req)
+
+	c.mutex.Lock()
+	shared := c.sharedStreams[streamKey(req)]
+	c.mutex.Unlock()
+	for shared.subscriberCount() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(send)
+
+	// every subscriber's events end, then it gets the terminal error
+	done := make(chan struct{})
+	for _, sub := range []struct {
+		eventch <-chan *Event
+		errch   <-chan error
+	}{{eventch1, errch1}, {eventch2, errch2}} {
+		sub := sub
+		go func() {
+			defer func() { done <- struct{}{} }()
+			var data []string
+			for event := range sub.eventch {
+				data = append(data, string(event.Data))
+			}
+			equals(t, []string{"shared"}, data)
+			equals(t, ErrStreamIsClosed, <-sub.errch)
+			_, open := <-sub.errch
+			assert(t, !open, "errors should be closed after the terminal error")
+		}()
+	}
+	<-done
+	<-done
+}
+
+func TestClient_CoalesceStreamsSettings(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Write([]byte("event: price\ndata: 1\n\nevent: trade\ndata: 2\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.CoalesceStreams = true
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var data []string
+	for r := range collect(c.Stream(req, StreamEventFilter(func(event *Event) bool { return event.Type == "price" }))) {
+		if r.Event != nil {
+			data = append(data, string(r.Event.Data))
+		}
+	}
+	// the filter applies, so the stream didn't share an upstream
+	equals(t, []string{"1"}, data)
+	assert(t, !coalescable(req.WithContext(WithEventTypes(context.Background(), "price"))), "streams with settings shouldn't be coalesced")
+	assert(t, coalescable(req.WithContext(WithMetadata(context.Background(), Metadata{"sub": "1"}))), "metadata is applied per subscriber")
+	equals(t, int32(1), atomic.LoadInt32(&connections))
+}
@@ -0,0 +1,56 @@
+package sse
+
+import (
+	"encoding"
+	"fmt"
+)
+
+// Codec serializes the values of event payloads. It is used by every feature
+// decoding payloads into typed values, e.g. SchemaRegistry, so any
+// serialization format can be plugged in.
+type Codec interface {
+	Decode(data []byte, v interface{}) error
+	Encode(v interface{}) ([]byte, error)
+}
+
+// CodecFuncs adapts a pair of marshal and unmarshal functions to a Codec,
+// e.g. for msgpack:
+//
+//	sse.CodecFuncs{MarshalFunc: msgpack.Marshal, UnmarshalFunc: msgpack.Unmarshal}
+type CodecFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+// Decode calls UnmarshalFunc
+func (c CodecFuncs) Decode(data []byte, v interface{}) error {
+	return c.UnmarshalFunc(data, v)
+}
+
+// Encode calls MarshalFunc
+func (c CodecFuncs) Encode(v interface{}) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+// BinaryCodec is a Codec for values implementing encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler, as generated protobuf messages of gogo/protobuf
+// and many other binary formats do
+type BinaryCodec struct{}
+
+// Decode unmarshals data into v, which must implement encoding.BinaryUnmarshaler
+func (BinaryCodec) Decode(data []byte, v interface{}) error {
+	u, ok := v.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("%T doesn't implement encoding.BinaryUnmarshaler", v)
+	}
+	return u.UnmarshalBinary(data)
+}
+
+// Encode marshals v, which must implement encoding.BinaryMarshaler
+func (BinaryCodec) Encode(v interface{}) ([]byte, error) {
+	m, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("%T doesn't implement encoding.BinaryMarshaler", v)
+	}
+	return m.MarshalBinary()
+}
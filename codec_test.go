@@ -0,0 +1,56 @@
+package sse
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBinaryCodec(t *testing.T) {
+	sent := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := BinaryCodec{}.Encode(sent)
+	ok(t, err)
+
+	var received time.Time
+	ok(t, BinaryCodec{}.Decode(data, &received))
+	equals(t, sent, received)
+
+	_, err = BinaryCodec{}.Encode("not binary")
+	assert(t, err != nil, "should've errored but didn't")
+}
+
+func TestSchemaRegistry_Codec(t *testing.T) {
+	registry := NewSchemaRegistry()
+	// payloads are plain numbers, the event ID holds the version
+	registry.Codec = CodecFuncs{
+		MarshalFunc: func(v interface{}) ([]byte, error) {
+			return []byte(strconv.Itoa(*v.(*int))), nil
+		},
+		UnmarshalFunc: func(data []byte, v interface{}) (err error) {
+			*v.(*int), err = strconv.Atoi(string(data))
+			return err
+		},
+	}
+	registry.Version = func(event *Event) (int, bool) {
+		version, err := strconv.Atoi(event.LastEventID)
+		return version, err == nil
+	}
+	registry.Register("count", Schema{
+		Version: 1,
+		New:     func() interface{} { return new(int) },
+		Upgrade: func(value interface{}) (interface{}, error) {
+			// version 2 counts in thousands
+			n := *value.(*int) * 1000
+			return &n, nil
+		},
+	})
+	registry.Register("count", Schema{Version: 2, New: func() interface{} { return new(int) }})
+
+	value, err := registry.Decode(&Event{Type: "count", LastEventID: "1", Data: []byte("3")})
+	ok(t, err)
+	equals(t, 3000, *value.(*int))
+
+	value, err = registry.Decode(&Event{Type: "count", LastEventID: "2", Data: []byte("3")})
+	ok(t, err)
+	equals(t, 3, *value.(*int))
+}
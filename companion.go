@@ -0,0 +1,202 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAcksDropped is passed to Companion.OnError, wrapped with their count,
+// when acks were dropped because the companion endpoint couldn't keep up
+var ErrAcksDropped = errors.New("companion acks dropped")
+
+// Companion configures a side-channel endpoint that some SSE protocols require
+// the client to POST heartbeats or per-event acknowledgements to in order to
+// keep the subscription alive. It runs while a connection of the stream is open.
+type Companion struct {
+	// URLTemplate is the URL to POST to. "{id}" is replaced by the ID of the
+	// acknowledged event, or the last event ID received for heartbeats, and
+	// "{type}" by the type of the acknowledged event.
+	URLTemplate string
+	// Interval is the time between heartbeats. Zero disables heartbeats.
+	Interval time.Duration
+	// AckEvents POSTs an acknowledgement for every event received
+	AckEvents bool
+	// Payload builds the body of a request, event is nil for heartbeats.
+	// Requests have no body if Payload is nil.
+	Payload func(event *Event) (contentType string, body []byte)
+	// OnError, if set, is called when a request fails or responds with a
+	// status code other than 2xx, and with ErrAcksDropped
+	OnError func(error)
+}
+
+// companionAckQueueSize is how many acks can wait to be sent before new ones are dropped
+const companionAckQueueSize = 64
+
+// companionRunner sends the heartbeats and acks of a connection
+type companionRunner struct {
+	// dropped counts the acks dropped since last reported, first for
+	// 64-bit alignment of atomic operations
+	dropped    int64
+	companion  *Companion
+	httpClient *http.Client
+	ctx        context.Context
+	cancel     context.CancelFunc
+	acks       chan companionAck
+	stop       chan struct{}
+	done       chan struct{}
+	lastID     string
+}
+
+// startCompanion starts sending heartbeats and acks for a connection, returns
+// nil if there is no Companion. Requests in flight are aborted when ctx is
+// done or the runner is closed.
+func (c *Client) startCompanion(ctx context.Context) *companionRunner {
+	if c.Companion == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &companionRunner{
+		companion:  c.Companion,
+		httpClient: c.HTTPClient,
+		ctx:        ctx,
+		cancel:     cancel,
+		acks:       make(chan companionAck, companionAckQueueSize),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// companionAck is what is kept of an event to acknowledge it
+type companionAck struct {
+	id, eventType string
+	// event is passed to the Payload of the ack, if there is one
+	event *Event
+}
+
+// acknowledgement returns what ack needs of event. It is taken before the
+// event is handed to the consumer, who may release a pooled event, and so
+// have it reused, as soon as it has it.
+func (r *companionRunner) acknowledgement(event *Event) companionAck {
+	if r == nil {
+		return companionAck{}
+	}
+	ack := companionAck{id: event.LastEventID, eventType: event.Type}
+	if r.companion.AckEvents && r.companion.Payload != nil {
+		ack.event = event
+		if event.pooled {
+			ack.event = event.Clone()
+		}
+	}
+	return ack
+}
+
+// ack queues an acknowledgement, see acknowledgement. It never blocks the
+// stream: acks are dropped, and counted, while the queue is full.
+func (r *companionRunner) ack(ack companionAck) {
+	if r == nil {
+		return
+	}
+	select {
+	case r.acks <- ack:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// close stops the runner, aborting the request in flight, and waits for it to exit
+func (r *companionRunner) close() {
+	if r == nil {
+		return
+	}
+	close(r.stop)
+	r.cancel()
+	<-r.done
+}
+
+func (r *companionRunner) run() {
+	defer close(r.done)
+
+	var tick <-chan time.Time
+	if r.companion.Interval > 0 {
+		ticker := time.NewTicker(r.companion.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			r.post(r.lastID, "", nil)
+		case ack := <-r.acks:
+			r.reportDropped()
+			if ack.id != "" {
+				r.lastID = ack.id
+			}
+			if r.companion.AckEvents {
+				r.post(ack.id, ack.eventType, ack.event)
+			}
+		case <-r.stop:
+			r.reportDropped()
+			return
+		}
+	}
+}
+
+// reportDropped passes the count of acks dropped since the last report to OnError
+func (r *companionRunner) reportDropped() {
+	if n := atomic.SwapInt64(&r.dropped, 0); n > 0 {
+		r.fail(fmt.Errorf("%w: %d", ErrAcksDropped, n))
+	}
+}
+
+// post sends a heartbeat (nil event) or an ack to the companion endpoint
+func (r *companionRunner) post(id, eventType string, event *Event) {
+	url := strings.NewReplacer("{id}", id, "{type}", eventType).Replace(r.companion.URLTemplate)
+
+	var body io.Reader
+	var contentType string
+	if r.companion.Payload != nil {
+		var payload []byte
+		contentType, payload = r.companion.Payload(event)
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	req = req.WithContext(r.ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		r.fail(fmt.Errorf("companion endpoint %s responded with status code %d", url, resp.StatusCode))
+	}
+}
+
+func (r *companionRunner) fail(err error) {
+	if r.companion.OnError != nil {
+		r.companion.OnError(err)
+	}
+}
@@ -0,0 +1,110 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_companionRunner(t *testing.T) {
+	requests := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- r.Method + " " + r.URL.Path + " " + r.Header.Get("Content-Type") + " " + string(body)
+	}))
+	defer server.Close()
+
+	c := &Client{
+		HTTPClient: server.Client(),
+		Companion: &Companion{
+			URLTemplate: server.URL + "/ack/{type}/{id}",
+			AckEvents:   true,
+			Payload: func(event *Event) (string, []byte) {
+				return "text/plain", event.Data
+			},
+		},
+	}
+
+	companion := c.startCompanion(context.Background())
+	companion.ack(companion.acknowledgement(&Event{LastEventID: "7", Type: "update", Data: []byte("hi")}))
+	equals(t, "POST /ack/update/7 text/plain hi", <-requests)
+	companion.close()
+
+	// no companion configured
+	c.Companion = nil
+	companion = c.startCompanion(context.Background())
+	companion.ack(companion.acknowledgement(&Event{}))
+	companion.close()
+}
+
+func Test_companionRunnerSlowEndpoint(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	c := &Client{
+		HTTPClient: server.Client(),
+		Companion: &Companion{
+			URLTemplate: server.URL + "/ack/{id}",
+			AckEvents:   true,
+			OnError: func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	companion := c.startCompanion(ctx)
+	companion.ack(companion.acknowledgement(&Event{LastEventID: "1"}))
+	<-started
+
+	// the endpoint hangs on the first ack, the stream doesn't wait for it
+	acked := make(chan struct{})
+	go func() {
+		defer close(acked)
+		for i := 0; i < 2*companionAckQueueSize; i++ {
+			companion.ack(companion.acknowledgement(&Event{LastEventID: "2"}))
+		}
+	}()
+	select {
+	case <-acked:
+	case <-time.After(time.Second):
+		t.Fatal("ack blocked on a full queue")
+	}
+
+	// closing aborts the request in flight
+	closed := make(chan struct{})
+	go func() {
+		companion.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close waited for the request in flight")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	dropped := false
+	for _, err := range errs {
+		if errors.Is(err, ErrAcksDropped) {
+			dropped = true
+			equals(t, "companion acks dropped: 64", err.Error())
+		}
+	}
+	assert(t, dropped, "expected ErrAcksDropped, got %v", errs)
+}
@@ -0,0 +1,57 @@
+package sse
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// ContentTypeCheck is how strictly the Content-Type of responses is checked
+// before parsing them as a stream
+type ContentTypeCheck int
+
+const (
+	// ContentTypeLenient accepts text/event-stream, as well as the types of
+	// non-conforming servers sending streams as text/plain or
+	// application/octet-stream or without a Content-Type. Anything else, e.g.
+	// an HTML error page, is rejected.
+	ContentTypeLenient ContentTypeCheck = iota
+	// ContentTypeStrict only accepts text/event-stream
+	ContentTypeStrict
+	// ContentTypeUnchecked parses any response as a stream
+	ContentTypeUnchecked
+)
+
+// ContentTypeError is returned when a response has a Content-Type that isn't
+// accepted by the Client's ContentTypeCheck
+type ContentTypeError struct {
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected content type from stream: %q", e.ContentType)
+}
+
+// checkContentType checks the Content-Type of resp
+func (c ContentTypeCheck) checkContentType(resp *http.Response) error {
+	if c == ContentTypeUnchecked {
+		return nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" && c == ContentTypeLenient {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return &ContentTypeError{ContentType: contentType}
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return nil
+	case c == ContentTypeLenient && (mediaType == "text/plain" || mediaType == "application/octet-stream"):
+		return nil
+	default:
+		return &ContentTypeError{ContentType: contentType}
+	}
+}
@@ -0,0 +1,53 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentTypeCheck_checkContentType(t *testing.T) {
+	tests := []struct {
+		testname    string
+		check       ContentTypeCheck
+		contentType string
+		accepted    bool
+	}{
+		{"LenientEventStream", ContentTypeLenient, "text/event-stream; charset=utf-8", true},
+		{"LenientPlain", ContentTypeLenient, "text/plain; charset=utf-8", true},
+		{"LenientMissing", ContentTypeLenient, "", true},
+		{"LenientHTML", ContentTypeLenient, "text/html; charset=utf-8", false},
+		{"LenientJSON", ContentTypeLenient, "application/json", false},
+		{"LenientInvalid", ContentTypeLenient, "text/", false},
+		{"StrictEventStream", ContentTypeStrict, "text/event-stream", true},
+		{"StrictPlain", ContentTypeStrict, "text/plain", false},
+		{"StrictMissing", ContentTypeStrict, "", false},
+		{"UncheckedHTML", ContentTypeUnchecked, "text/html", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testname, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.contentType != "" {
+				resp.Header.Set("Content-Type", tt.contentType)
+			}
+			err := tt.check.checkContentType(resp)
+			if tt.accepted {
+				ok(t, err)
+			} else {
+				equals(t, &ContentTypeError{ContentType: tt.contentType}, err)
+			}
+		})
+	}
+}
+
+func TestClient_ContentTypeCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>\n\nMaintenance\n\n</body></html>"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	_, errch := NewClient(server.Client()).Stream(req)
+	equals(t, &ContentTypeError{ContentType: "text/html; charset=utf-8"}, <-errch)
+}
@@ -0,0 +1,162 @@
+package sse
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Directives a server can send in control events
+const (
+	// ControlReset makes the client reconnect without a Last-Event-ID header
+	ControlReset = "reset"
+	// ControlRedirect makes the client reconnect to another URL, e.g. "redirect https://eu.example.com/events".
+	// Like net/http does to forward credentials, only URLs on the same host,
+	// or one of its subdomains, are followed, and not from https to http,
+	// since the request's headers and credentials go along.
+	ControlRedirect = "redirect"
+	// ControlBackoff makes the client disconnect and wait before reconnecting,
+	// e.g. "backoff 30s" or "backoff 30" (seconds)
+	ControlBackoff = "backoff"
+)
+
+// Control is a directive received in a control event. Control events have the
+// type set in Client.ControlEventType and their data is the directive
+// followed by its argument, if any.
+type Control struct {
+	Event     *Event
+	Directive string
+	// URL is where to reconnect for ControlRedirect
+	URL *url.URL
+	// Delay is how long to wait before reconnecting for ControlBackoff
+	Delay time.Duration
+}
+
+// InvalidControlError is passed through the error channel when a control
+// event couldn't be parsed. The directive is ignored and the stream goes on.
+type InvalidControlError struct {
+	Event *Event
+	Err   error
+}
+
+func (e *InvalidControlError) Error() string {
+	return fmt.Sprintf("invalid control event: %s", e.Err.Error())
+}
+
+// controlError ends a connection to apply a control directive
+type controlError struct {
+	ctrl *Control
+}
+
+func (e *controlError) Error() string {
+	return fmt.Sprintf("control event from stream: %s", e.ctrl.Directive)
+}
+
+// parseControl parses the data of a control event, relative URLs are resolved against base
+func parseControl(event *Event, base *url.URL) (*Control, error) {
+	fields := strings.Fields(string(bytes.TrimSpace(event.Data)))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("control event has no directive")
+	}
+
+	ctrl := &Control{Event: event, Directive: fields[0]}
+	switch ctrl.Directive {
+	case ControlReset:
+	case ControlRedirect:
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("redirect control event has no url")
+		}
+		u, err := base.Parse(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		if !redirectAllowed(base, u) {
+			return nil, fmt.Errorf("redirect to %s leaves the origin of %s", u.Redacted(), base.Redacted())
+		}
+		ctrl.URL = u
+	case ControlBackoff:
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("backoff control event has no delay")
+		}
+		delay, err := parseDelay(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		ctrl.Delay = delay
+	default:
+		return nil, fmt.Errorf("unknown control directive %q", ctrl.Directive)
+	}
+
+	return ctrl, nil
+}
+
+// redirectAllowed reports whether a redirect from from to to keeps the
+// request's credentials safe: to is on the host of from or one of its
+// subdomains, and isn't a downgrade from https
+func redirectAllowed(from, to *url.URL) bool {
+	if to.Scheme != "http" && to.Scheme != "https" {
+		return false
+	}
+	if from.Scheme == "https" && to.Scheme != "https" {
+		return false
+	}
+	host, parent := to.Hostname(), from.Hostname()
+	if host == parent {
+		return true
+	}
+	// IP addresses have no subdomains
+	if net.ParseIP(parent) != nil {
+		return false
+	}
+	return strings.HasSuffix(host, "."+parent)
+}
+
+// parseDelay parses either a duration ("1m30s") or a number of seconds,
+// neither of which may be negative
+func parseDelay(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsNaN(seconds) || seconds < 0 || seconds*float64(time.Second) > math.MaxInt64 {
+			return 0, fmt.Errorf("invalid delay %q", value)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	delay, err := time.ParseDuration(value)
+	if err == nil && delay < 0 {
+		return 0, fmt.Errorf("invalid delay %q", value)
+	}
+	return delay, err
+}
+
+// applyControl returns the request to reconnect with after a control directive
+func applyControl(req *http.Request, ctrl *Control) *http.Request {
+	req = cloneRequest(req)
+	switch ctrl.Directive {
+	case ControlReset:
+		req.Header.Del("Last-Event-ID")
+	case ControlRedirect:
+		req.URL = ctrl.URL
+		req.Host = ""
+	}
+	return req
+}
+
+// cloneRequest copies req, along with its URL and headers so they can be changed
+func cloneRequest(req *http.Request) *http.Request {
+	clone := req.WithContext(req.Context())
+
+	u := *req.URL
+	clone.URL = &u
+
+	clone.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		clone.Header[k] = append([]string(nil), v...)
+	}
+
+	return clone
+}
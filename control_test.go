@@ -0,0 +1,85 @@
+package sse
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_parseControl(t *testing.T) {
+	base, err := url.Parse("https://example.com/v1/events")
+	ok(t, err)
+
+	tests := []struct {
+		testname  string
+		data      string
+		directive string
+		url       string
+		delay     time.Duration
+		shouldErr bool
+	}{
+		{"reset", "reset", ControlReset, "", 0, false},
+		{"absolute redirect", "redirect https://eu.example.com/events", ControlRedirect, "https://eu.example.com/events", 0, false},
+		{"relative redirect", "redirect /v2/events", ControlRedirect, "https://example.com/v2/events", 0, false},
+		{"backoff seconds", "backoff 30", ControlBackoff, "", 30 * time.Second, false},
+		{"backoff duration", "backoff 1m30s", ControlBackoff, "", 90 * time.Second, false},
+		{"redirect to another host", "redirect https://evil.example.org/events", "", "", 0, true},
+		{"redirect to a lookalike host", "redirect https://notexample.com/events", "", "", 0, true},
+		{"redirect downgrading to http", "redirect http://example.com/events", "", "", 0, true},
+		{"redirect to another scheme", "redirect ftp://example.com/events", "", "", 0, true},
+		{"negative backoff", "backoff -30", "", "", 0, true},
+		{"negative backoff duration", "backoff -1m", "", "", 0, true},
+		{"infinite backoff", "backoff +Inf", "", "", 0, true},
+		{"NaN backoff", "backoff NaN", "", "", 0, true},
+		{"backoff without delay", "backoff", "", "", 0, true},
+		{"unknown", "explode now", "", "", 0, true},
+		{"empty", "", "", "", 0, true},
+	}
+
+	for _, test := range tests {
+		ctrl, err := parseControl(&Event{Type: "control", Data: []byte(test.data)}, base)
+		if test.shouldErr {
+			assert(t, err != nil, "%s: should've errored but didn't", test.testname)
+			continue
+		}
+		ok(t, err)
+		equals(t, test.directive, ctrl.Directive)
+		equals(t, test.delay, ctrl.Delay)
+		if test.url != "" {
+			equals(t, test.url, ctrl.URL.String())
+		}
+	}
+}
+
+func Test_applyControl(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/events", nil)
+	ok(t, err)
+	req.Header.Set("Last-Event-ID", "42")
+
+	reset := applyControl(req, &Control{Directive: ControlReset})
+	equals(t, "", reset.Header.Get("Last-Event-ID"))
+	equals(t, "42", req.Header.Get("Last-Event-ID"))
+
+	target, err := url.Parse("https://eu.example.com/events")
+	ok(t, err)
+	redirected := applyControl(req, &Control{Directive: ControlRedirect, URL: target})
+	equals(t, "https://eu.example.com/events", redirected.URL.String())
+	equals(t, "https://example.com/events", req.URL.String())
+}
+
+func TestClient_handleEvent_invalidControl(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/events", nil)
+	ok(t, err)
+
+	c := &Client{ControlEventType: "control"}
+	var results []Result
+	emit := func(r Result) { results = append(results, r) }
+
+	event := &Event{Type: "control", Data: []byte("backoff soon")}
+	ok(t, c.handleEvent(req, event, []byte("event: control\ndata: backoff soon"), streamMetrics{}, emit, nil))
+	equals(t, 1, len(results))
+	invalid, isInvalid := results[0].Err.(*InvalidControlError)
+	assert(t, isInvalid, "expected an *InvalidControlError, got %v", results[0].Err)
+	equals(t, event, invalid.Event)
+}
@@ -0,0 +1,52 @@
+package sse
+
+import "io"
+
+// Decoder reads events from an SSE stream from any io.Reader, e.g. a file,
+// a test fixture or a stream relayed over another protocol
+type Decoder struct {
+	scanner *eventScanner
+}
+
+// NewDecoder creates a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{scanner: newEventScanner(r)}
+}
+
+// Buffer sets the initial size of the buffer events are read into and the
+// size of the largest event that can be read, see Client.MaxEventSize.
+// Larger events fail with ErrEventTooLarge. It must be called before
+// decoding.
+func (d *Decoder) Buffer(initial, max int) {
+	d.scanner.setBufferSizes(initial, max)
+}
+
+// Decode reads the next event. io.EOF is returned once the stream has ended.
+func (d *Decoder) Decode() (*Event, error) {
+	event := &Event{}
+	if err := d.DecodeInto(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// DecodeInto reads the next event into ev, reusing ev and the capacity of its
+// Data instead of allocating a new event, so high throughput consumers can
+// decode a whole stream with a single Event. ev is overwritten entirely.
+// io.EOF is returned once the stream has ended.
+func (d *Decoder) DecodeInto(ev *Event) error {
+	for {
+		eventBytes, err := d.scanner.scanEvent()
+		if err != nil {
+			return err
+		}
+		// comments carry no event, and readEventInto only returns an error
+		// if the block should be ignored
+		if isCommentBlock(eventBytes) {
+			continue
+		}
+		if err := readEventInto(ev, eventBytes); err == nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package sse
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder_DecodeInto(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader("event: update\ndata: first\n\ndata: second\n\n"))
+	event := &Event{Data: make([]byte, 0, 64)}
+	capacity := cap(event.Data)
+
+	ok(t, decoder.DecodeInto(event))
+	equals(t, &Event{Type: "update", Data: []byte("first")}, event)
+	equals(t, capacity, cap(event.Data))
+
+	ok(t, decoder.DecodeInto(event))
+	equals(t, &Event{Data: []byte("second")}, event)
+	equals(t, capacity, cap(event.Data))
+
+	equals(t, io.EOF, decoder.DecodeInto(event))
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(": comment\r\n\r\nid: 1\r\ndata: crlf\r\n\r\ndata: lf\n\ndata: last"))
+
+	var events []*Event
+	for {
+		event, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		ok(t, err)
+		events = append(events, event)
+	}
+	equals(t, []*Event{
+		{LastEventID: "1", Data: []byte("crlf")},
+		{Data: []byte("lf")},
+		{Data: []byte("last")},
+	}, events)
+}
+
+func TestDecoder_Buffer(t *testing.T) {
+	large := "data: " + strings.Repeat("x", 100*1024) + "\n\n"
+
+	decoder := NewDecoder(strings.NewReader(large))
+	_, err := decoder.Decode()
+	equals(t, ErrEventTooLarge, err)
+
+	decoder = NewDecoder(strings.NewReader(large))
+	decoder.Buffer(1024, 1024*1024)
+	event, err := decoder.Decode()
+	ok(t, err)
+	equals(t, 100*1024, len(event.Data))
+
+	decoder = NewDecoder(strings.NewReader("data: small\n\n" + large))
+	decoder.Buffer(0, 1024)
+	event, err = decoder.Decode()
+	ok(t, err)
+	equals(t, "small", string(event.Data))
+	_, err = decoder.Decode()
+	equals(t, ErrEventTooLarge, err)
+}
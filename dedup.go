@@ -0,0 +1,40 @@
+package sse
+
+import "strings"
+
+// EventIDComparator orders event IDs, returning a negative number if a comes
+// before b, zero if they are the same event and a positive number if a comes
+// after b, see Client.Deduplicate
+type EventIDComparator func(a, b string) int
+
+// CompareNumericIDs orders IDs that are non-negative integers in base 10, of
+// any size. An ID that isn't such an integer comes after any other, so it is
+// never taken for a duplicate.
+func CompareNumericIDs(a, b string) int {
+	if !isNumericID(a) || !isNumericID(b) {
+		return 1
+	}
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumericID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isDuplicate reports whether event doesn't come after last, the ID of the
+// last event received, when deduplicating
+func (c *Client) isDuplicate(event *Event, last string) bool {
+	return c.Deduplicate != nil && event.LastEventID != "" && last != "" && c.Deduplicate(event.LastEventID, last) <= 0
+}
@@ -0,0 +1,72 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompareNumericIDs(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1", "2", -1},
+		{"2", "2", 0},
+		{"10", "9", 1},
+		{"007", "7", 0},
+		{"123456789012345678901234567890", "123456789012345678901234567891", -1},
+		{"abc", "1", 1},
+		{"1", "abc", 1},
+	}
+
+	for _, test := range tests {
+		actual := CompareNumericIDs(test.a, test.b)
+		sign := 0
+		if actual < 0 {
+			sign = -1
+		} else if actual > 0 {
+			sign = 1
+		}
+		assert(t, sign == test.expected, "comparing %q and %q: expected %d, got %d", test.a, test.b, test.expected, actual)
+	}
+}
+
+func TestClient_Deduplicate(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server replays everything it has on every connection
+		ids := []string{"1", "2"}
+		if atomic.AddInt32(&connections, 1) > 1 {
+			ids = []string{"1", "2", "3"}
+		}
+		for _, id := range ids {
+			w.Write([]byte("id: " + id + "\ndata: " + id + "\n\n"))
+		}
+		w.Write([]byte("data: no id\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond), WithDeduplication(CompareNumericIDs))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var received []string
+	for r := range collect(c.Stream(req)) {
+		if r.Event != nil {
+			received = append(received, string(r.Event.Data))
+			if string(r.Event.Data) == "3" {
+				cancel()
+			}
+		}
+	}
+	// what follows the cancellation may be delivered too
+	assert(t, len(received) >= 4, "expected at least 4 events, got %q", received)
+	equals(t, "1,2,no id,3", strings.Join(received[:4], ","))
+}
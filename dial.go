@@ -0,0 +1,27 @@
+package sse
+
+import (
+	"context"
+	"net"
+)
+
+// DialContextFunc dials a connection ignoring the network and address
+// requested by http.Transport. It is meant to be used as
+// http.Transport.DialContext to reach a local SSE endpoint, e.g.
+//
+//	httpClient := &http.Client{
+//		Transport: &http.Transport{DialContext: sse.UnixSocketDialer("/run/agent.sock")},
+//	}
+//	client := sse.NewClient(httpClient)
+//
+// Request URLs still need a host (e.g. "http://agent/events"); it is only
+// used for the Host header.
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// UnixSocketDialer returns a DialContextFunc connecting to the unix socket at path
+func UnixSocketDialer(path string) DialContextFunc {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
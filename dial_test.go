@@ -0,0 +1,39 @@
+package sse
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUnixSocketDialer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets aren't supported")
+	}
+
+	dir, err := ioutil.TempDir("", "sse")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "sse.sock")
+	listener, err := net.Listen("unix", socket)
+	ok(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: hello\n\n"))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &http.Transport{DialContext: UnixSocketDialer(socket)}}
+	resp, err := httpClient.Get("http://agent/events")
+	ok(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	ok(t, err)
+	equals(t, "data: hello\n\n", string(body))
+}
@@ -0,0 +1,55 @@
+package sse
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// errorPipeBusy is ERROR_PIPE_BUSY, returned while all instances of a pipe are in use
+const errorPipeBusy syscall.Errno = 231
+
+// NamedPipeDialer returns a DialContextFunc connecting to the Windows named
+// pipe at path (e.g. `\\.\pipe\agent`). While every instance of the pipe is
+// busy it keeps retrying until ctx is done.
+//
+// The pipe is opened for synchronous I/O, so the connection doesn't support
+// deadlines: http.Client timeouts and cancelled requests only take effect once
+// the server writes to or closes the pipe. Servers should send heartbeats (see
+// Client.HeartbeatOnComment) for streams to be stopped promptly.
+func NamedPipeDialer(path string) DialContextFunc {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		for {
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err == nil {
+				return &pipeConn{File: f, addr: pipeAddr(path)}, nil
+			}
+			if pathErr, ok := err.(*os.PathError); !ok || pathErr.Err != errorPipeBusy {
+				return nil, err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+}
+
+// pipeAddr is the net.Addr of a named pipe
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn adapts a named pipe file to net.Conn
+type pipeConn struct {
+	*os.File
+	addr pipeAddr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.addr }
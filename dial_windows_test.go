@@ -0,0 +1,58 @@
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procCreateNamedPipeW = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = kernel32.NewProc("ConnectNamedPipe")
+)
+
+const (
+	pipeAccessDuplex = 0x3
+	pipeUnlimited    = 255
+)
+
+// serveNamedPipe accepts a single connection on a new named pipe at path and
+// answers its request with response
+func serveNamedPipe(t *testing.T, path, response string) {
+	name, err := syscall.UTF16PtrFromString(path)
+	ok(t, err)
+	handle, _, err := procCreateNamedPipeW.Call(uintptr(unsafe.Pointer(name)), pipeAccessDuplex, 0, pipeUnlimited, 4096, 4096, 0, 0)
+	if syscall.Handle(handle) == syscall.InvalidHandle {
+		t.Fatal(err)
+	}
+
+	go func() {
+		// ConnectNamedPipe fails with ERROR_PIPE_CONNECTED if the client was first
+		procConnectNamedPipe.Call(handle, 0)
+		pipe := os.NewFile(handle, path)
+		defer pipe.Close()
+
+		if _, err := http.ReadRequest(bufio.NewReader(pipe)); err != nil {
+			return
+		}
+		pipe.Write([]byte(response))
+	}()
+}
+
+func TestNamedPipeDialer(t *testing.T) {
+	const path = `\\.\pipe\sse-client-go-test`
+	serveNamedPipe(t, path, "HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nConnection: close\r\n\r\ndata: hello\n\n")
+
+	c := NewClient(&http.Client{
+		Transport: &http.Transport{DialContext: NamedPipeDialer(path)},
+	})
+	req, err := http.NewRequest(http.MethodGet, "http://agent/events", nil)
+	ok(t, err)
+
+	eventch, _ := c.Stream(req)
+	equals(t, "hello", string((<-eventch).Data))
+}
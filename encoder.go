@@ -0,0 +1,120 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errFieldNewline is returned by Encoder for event IDs or types that would
+// break out of their field
+var errFieldNewline = errors.New("field contains a line ending")
+
+// Encoder writes events in the SSE wire format
+type Encoder struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// NewEncoder creates an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes an event. Multi-line data is written as a data line per line,
+// so it is decoded back as is. The Timestamp and Metadata of ev aren't part
+// of the wire format and are left out.
+func (e *Encoder) Encode(ev *Event) error {
+	e.buf.Reset()
+	if err := writeEvent(&e.buf, ev); err != nil {
+		return err
+	}
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// writeEvent writes ev to buf in the wire format
+func writeEvent(buf *bytes.Buffer, ev *Event) error {
+	if strings.ContainsAny(ev.LastEventID, "\r\n") || strings.ContainsAny(ev.Type, "\r\n") {
+		return errFieldNewline
+	}
+
+	if ev.LastEventID != "" {
+		buf.WriteString("id: " + ev.LastEventID + "\n")
+	}
+	if ev.Type != "" {
+		buf.WriteString("event: " + ev.Type + "\n")
+	}
+	if ev.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(int64(ev.Retry/time.Millisecond), 10) + "\n")
+	}
+	for _, line := range splitLines(ev.Data) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// WriteTo writes the event to w in the wire format, like Encoder.Encode, e.g.
+// to proxy it onwards
+func (e *Event) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if err := writeEvent(&buf, e); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+// String returns the event in the wire format, like Encoder.Encode, e.g. for
+// logs or golden tests. An ID or type with a line ending, which the wire
+// format can't hold, is quoted instead.
+func (e *Event) String() string {
+	var buf bytes.Buffer
+	if writeEvent(&buf, e) != nil {
+		quoted := *e
+		if strings.ContainsAny(e.LastEventID, "\r\n") {
+			quoted.LastEventID = strconv.Quote(e.LastEventID)
+		}
+		if strings.ContainsAny(e.Type, "\r\n") {
+			quoted.Type = strconv.Quote(e.Type)
+		}
+		writeEvent(&buf, &quoted)
+	}
+	return buf.String()
+}
+
+// Comment writes a comment, e.g. to keep an idle connection alive.
+// Multi-line text is written as a comment line per line.
+func (e *Encoder) Comment(text string) error {
+	e.buf.Reset()
+	for _, line := range splitLines([]byte(text)) {
+		e.buf.WriteString(": ")
+		e.buf.Write(line)
+		e.buf.WriteByte('\n')
+	}
+	e.buf.WriteByte('\n')
+
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// splitLines splits data on any of the line endings of the wire format
+func splitLines(data []byte) [][]byte {
+	var lines [][]byte
+	for {
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			return append(lines, data)
+		}
+		lines = append(lines, data[:i])
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			i++
+		}
+		data = data[i+1:]
+	}
+}
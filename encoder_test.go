@@ -0,0 +1,70 @@
+package sse
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncoder_Encode(t *testing.T) {
+	tests := []struct {
+		testname  string
+		event     *Event
+		expected  string
+		shouldErr bool
+	}{
+		{"data only", &Event{Data: []byte("hello")}, "data: hello\n\n", false},
+		{"all fields", &Event{LastEventID: "7", Type: "update", Retry: 5 * time.Second, Data: []byte("hello")}, "id: 7\nevent: update\nretry: 5000\ndata: hello\n\n", false},
+		{"multi-line data", &Event{Data: []byte("line 1\nline 2\r\nline 3\rline 4")}, "data: line 1\ndata: line 2\ndata: line 3\ndata: line 4\n\n", false},
+		{"empty data", &Event{}, "data: \n\n", false},
+		{"newline in type", &Event{Type: "a\nb"}, "", true},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := NewEncoder(&buf).Encode(test.event)
+		if test.shouldErr {
+			assert(t, err != nil, "%s: should've errored but didn't", test.testname)
+			continue
+		}
+		ok(t, err)
+		equals(t, test.expected, buf.String())
+	}
+}
+
+func TestEvent_String(t *testing.T) {
+	event := &Event{LastEventID: "7", Type: "update", Data: []byte("line 1\nline 2")}
+	equals(t, "id: 7\nevent: update\ndata: line 1\ndata: line 2\n\n", event.String())
+
+	var buf bytes.Buffer
+	n, err := event.WriteTo(&buf)
+	ok(t, err)
+	equals(t, event.String(), buf.String())
+	equals(t, int64(buf.Len()), n)
+
+	// fields the wire format can't hold are quoted rather than written as is
+	equals(t, "event: \"a\\nb\"\ndata: \n\n", (&Event{Type: "a\nb"}).String())
+	_, err = (&Event{Type: "a\nb"}).WriteTo(&buf)
+	equals(t, errFieldNewline, err)
+}
+
+func TestEncoder_roundTrip(t *testing.T) {
+	events := []*Event{
+		{LastEventID: "1", Type: "update", Data: []byte(`{"url":"http://x"}`)},
+		{Data: []byte("line 1\n\nline 3")},
+	}
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	ok(t, encoder.Comment("keep-alive"))
+	for _, event := range events {
+		ok(t, encoder.Encode(event))
+	}
+
+	decoder := NewDecoder(&buf)
+	for _, expected := range events {
+		event, err := decoder.Decode()
+		ok(t, err)
+		equals(t, expected, event)
+	}
+}
@@ -0,0 +1,50 @@
+package sse
+
+import "fmt"
+
+// EventError is passed through the error channel when the server sends an
+// event whose type is listed in Client.ErrorEventTypes
+type EventError struct {
+	// Event is the event the error was built from
+	Event *Event
+	// Code is the "code" field of a JSON payload, if any
+	Code string
+	// Message is the "message" (or "error") field of a JSON payload,
+	// or the raw data when the payload isn't JSON
+	Message string
+}
+
+func (e *EventError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s event from stream: %s (%s)", e.Event.Type, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%s event from stream: %s", e.Event.Type, e.Message)
+}
+
+// newEventError creates an *EventError from an event, using the fields
+// of its data when they can be decoded
+func newEventError(event *Event) *EventError {
+	eventErr := &EventError{
+		Event:   event,
+		Message: string(event.Data),
+	}
+
+	if code, message, ok := decodeErrorPayload(event.Data); ok {
+		eventErr.Code = code
+		if message != "" {
+			eventErr.Message = message
+		}
+	}
+
+	return eventErr
+}
+
+// isErrorEvent checks if the event type is one of the configured error event types
+func (c *Client) isErrorEvent(event *Event) bool {
+	for _, t := range c.ErrorEventTypes {
+		if event.Type == t {
+			return true
+		}
+	}
+	return false
+}
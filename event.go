@@ -5,15 +5,41 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"time"
 )
 
 // Event is a struct holding all data from a single sse event
 type Event struct {
 	LastEventID string
 	Type        string
-	Data        []byte
+	// Data is owned by the event: it is copied out of the buffer the stream
+	// is read into, so it can be kept after the next event is read. Only
+	// pooled events reuse it once released, see Client.PoolEvents.
+	Data []byte
+	// Timestamp is the time the server produced the event, when configured
+	// on the Client via TimestampField or TimestampJSONPath
+	Timestamp time.Time
+	// Metadata is the metadata of the stream the event was received on,
+	// see WithMetadata
+	Metadata Metadata
+	// Retry is the reconnection time sent in the event's retry field, zero if
+	// it had none. Reconnecting streams wait this long before reconnecting.
+	Retry time.Duration
+
+	// pooled is set on events of the pool while they are handed out, see
+	// Client.PoolEvents
+	pooled bool
+}
+
+// DataString returns the data of the event as a string
+func (e *Event) DataString() string {
+	return string(e.Data)
 }
 
+// MessageEventType is the type browsers dispatch events without an event
+// field as, see Client.DefaultEventType
+const MessageEventType = "message"
+
 const (
 	eventTypeEvent = "event"
 	eventTypeData  = "data"
@@ -21,17 +47,42 @@ const (
 	eventTypeRetry = "retry"
 )
 
+// errEmptyEvent is returned by readEventInto for blocks without any line
+var errEmptyEvent = errors.New("data is empty")
+
 func readEvent(data []byte) (*Event, error) {
 	event := &Event{}
+	if err := readEventInto(event, data); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
 
-	if len(data) < 1 {
-		return nil, errors.New("data is empty")
+// readEventInto parses an event into event, reusing the capacity of its Data
+// so a caller decoding many events can avoid allocating for each of them.
+// Data is copied out of data, which may be reused afterwards.
+func readEventInto(event *Event, data []byte) error {
+	if len(bytes.Trim(data, "\r\n")) < 1 {
+		return errEmptyEvent
 	}
 
-	// make crlf into lf for the fieldsfunc to work easier
-	bytes.Replace(data, []byte("\n\r"), []byte("\n"), -1)
-	// Split into each line by newlines
-	for _, line := range bytes.FieldsFunc(data, func(r rune) bool { return r == '\n' || r == '\r' }) {
+	// the previous strings are reused when unchanged, sparing an allocation
+	// to callers decoding many events into the same Event
+	lastType, lastID := event.Type, event.LastEventID
+	*event = Event{Data: event.Data[:0], pooled: event.pooled}
+
+	// Split into each line by newlines, skipping empty lines
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+
 		// Per the spec:
 		// If the line starts with a U+003A COLON character (:)
 		// 		Ignore the line.
@@ -46,10 +97,9 @@ func readEvent(data []byte) (*Event, error) {
 		// 		Collect the characters on the line before the first U+003A COLON character (:), and let field be that string.
 		//		Collect the characters on the line after the first U+003A COLON character (:), and let value be that string. If value starts with a U+0020 SPACE character, remove it from value.
 		//		Process the field using the steps described below, using field as the field name and value as the field value.
-		if bytes.Contains(line, []byte(":")) {
-			splitLine := bytes.Split(line, []byte(":"))
-			field = splitLine[0]
-			value = splitLine[1]
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field = line[:i]
+			value = line[i+1:]
 			// trim space from beginning of value
 			value = bytes.TrimPrefix(value, []byte(" "))
 		} else {
@@ -64,20 +114,31 @@ func readEvent(data []byte) (*Event, error) {
 		switch {
 		case bytes.Equal(field, []byte(eventTypeEvent)):
 			// Set the event type buffer to field value.
-			event.Type = string(value)
+			event.Type = lastType
+			if string(value) != lastType {
+				event.Type = string(value)
+			}
 		case bytes.Equal(field, []byte(eventTypeData)):
 			// Append the field value to the data buffer,
 			// then append a single U+000A LINE FEED (LF) character to the data buffer.
-			event.Data = append(value, []byte("\n")...)
+			event.Data = append(append(event.Data, value...), '\n')
 		case bytes.Equal(field, []byte(eventTypeID)):
 			// If the field value does not contain U+0000 NULL,
 			// then set the last event ID buffer to the field value.
 			if !bytes.Contains(value, []byte("\000")) {
-				event.LastEventID = string(value)
+				event.LastEventID = lastID
+				if string(value) != lastID {
+					event.LastEventID = string(value)
+				}
 			}
 			// Otherwise, ignore the field.
 		case bytes.Equal(field, []byte(eventTypeRetry)):
-			// TODO: Unimplemented currently
+			// If the field value consists of only ASCII digits, then interpret the field value
+			// as an integer in base ten, and set the event stream's reconnection time to that integer.
+			// Otherwise, ignore the field.
+			if milliseconds, ok := parseDigits(value); ok {
+				event.Retry = time.Duration(milliseconds) * time.Millisecond
+			}
 		default:
 			// ignore the line
 		}
@@ -88,11 +149,28 @@ func readEvent(data []byte) (*Event, error) {
 	// then remove the last character from the data buffer.
 	event.Data = bytes.TrimSuffix(event.Data, []byte("\n"))
 
-	return event, nil
+	return nil
+}
+
+// parseDigits parses a value made of ASCII digits only
+func parseDigits(value []byte) (int64, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	var n int64
+	for _, b := range value {
+		if b < '0' || b > '9' {
+			return 0, false
+		}
+		n = n*10 + int64(b-'0')
+		if n > int64(time.Duration(1<<63-1)/time.Millisecond) {
+			return 0, false
+		}
+	}
+	return n, true
 }
 
-// eventScannerFunc function to use for the event scanner
-// An event is complete when there is an empty line, so two line endings signals the end of the event
+// scanLines splits a stream into lines, without their line endings.
 //
 // As per the spec:
 // The stream must then be parsed by reading everything line by line,
@@ -100,49 +178,140 @@ func readEvent(data []byte) (*Event, error) {
 // a single U+000A LINE FEED (LF) character not preceded by a U+000D CARRIAGE RETURN (CR) character,
 // and a single U+000D CARRIAGE RETURN (CR) character not followed by a U+000A LINE FEED (LF) character
 // being the ways in which a line can end.
-var eventScannerFunc bufio.SplitFunc = func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	if atEOF && len(data) == 0 {
 		return 0, nil, nil
 	}
 
-	// reader has no more data
-	if atEOF {
-		return len(data), data, nil
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		// the LF of a CRLF may be in the next read
+		return 0, nil, nil
 	}
 
-	// a U+000D CARRIAGE RETURN U+000A LINE FEED (CRLF) character pair
-	if i := bytes.Index(data, []byte("\r\n\r\n")); i >= 0 {
-		return i + 1, data[0:i], nil
-	}
-	// a single U+000A LINE FEED (LF) character not preceded by a U+000D CARRIAGE RETURN (CR) character
-	if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
-		return i + 1, data[0:i], nil
-	}
-	// a single U+000D CARRIAGE RETURN (CR) character not followed by a U+000A LINE FEED (LF) character
-	if i := bytes.Index(data, []byte("\r\r")); i >= 0 {
-		return i + 1, data[0:i], nil
+	// reader has no more data, the remaining data is the last line
+	if atEOF {
+		return len(data), data, nil
 	}
 
 	// didn't find the end of a line
 	return 0, nil, nil
 }
 
+// eventScanner reads a stream line by line, gathering the lines of each
+// event into a block ending at the first empty line. Blocks have their lines
+// separated by LF whichever line endings the stream uses.
 type eventScanner struct {
 	*bufio.Scanner
+	// consumed is the number of bytes of the body taken by the events scanned so far
+	consumed int64
+	// started is set once the first line has been scanned
+	started bool
+	// block is the event being gathered, reused for every event
+	block []byte
+	// maxEventSize is the size blocks may not exceed
+	maxEventSize int
+	// err is the error scanning ended with, once it has
+	err error
 }
 
+// byteOrderMark is the UTF-8 encoding of U+FEFF, which the spec strips from
+// the start of a stream
+var byteOrderMark = []byte("\xEF\xBB\xBF")
+
 func newEventScanner(body io.Reader) *eventScanner {
-	scanner := bufio.NewScanner(body)
-	scanner.Split(eventScannerFunc)
-	return &eventScanner{scanner}
+	scanner := &eventScanner{Scanner: bufio.NewScanner(body), maxEventSize: bufio.MaxScanTokenSize}
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := scanLines(data, atEOF)
+		scanner.consumed += int64(advance)
+		return advance, token, err
+	})
+	return scanner
+}
+
+// setBufferSizes sets the initial size of the buffer lines are read into and
+// the size of the largest event, the bufio.Scanner defaults when zero. It
+// must be called before scanning.
+func (scanner *eventScanner) setBufferSizes(initial, max int) {
+	if initial <= 0 && max <= 0 {
+		return
+	}
+	if max <= 0 {
+		max = bufio.MaxScanTokenSize
+	}
+	if initial <= 0 {
+		initial = 4096
+	}
+	if initial > max {
+		initial = max
+	}
+	scanner.maxEventSize = max
+	scanner.Buffer(make([]byte, 0, initial), max)
 }
 
+// scanEvent returns the block of the next event, which is only valid until
+// the next call. A block left unfinished by the end of the stream is
+// returned as the last event.
 func (scanner *eventScanner) scanEvent() ([]byte, error) {
-	if scanner.Scan() {
-		return scanner.Bytes(), nil
+	if scanner.err != nil {
+		return nil, scanner.err
 	}
+
+	scanner.block = scanner.block[:0]
+	lines := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !scanner.started {
+			// stripped from the line only, so the BOM still counts in consumed
+			scanner.started = true
+			line = bytes.TrimPrefix(line, byteOrderMark)
+		}
+
+		if len(line) == 0 {
+			if lines > 0 {
+				return scanner.block, nil
+			}
+			// blank lines between events
+			continue
+		}
+
+		size := len(scanner.block) + len(line)
+		if lines > 0 {
+			size++
+		}
+		if size > scanner.maxEventSize {
+			scanner.err = ErrEventTooLarge
+			return nil, scanner.err
+		}
+		if lines > 0 {
+			scanner.block = append(scanner.block, '\n')
+		}
+		scanner.block = append(scanner.block, line...)
+		lines++
+	}
+
 	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			err = ErrEventTooLarge
+		}
+		scanner.err = err
 		return nil, err
 	}
+	if lines > 0 {
+		scanner.err = io.EOF
+		return scanner.block, nil
+	}
+	scanner.err = io.EOF
 	return nil, io.EOF
 }
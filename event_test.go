@@ -1,6 +1,13 @@
 package sse
 
-import "testing"
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
 
 func Test_readEvent(t *testing.T) {
 	tests := []struct {
@@ -39,6 +46,57 @@ func Test_readEvent(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"multi-line data",
+			"data: first line\ndata:   indented\ndata: last line\n",
+			&Event{
+				Data: []byte("first line\n  indented\nlast line"),
+			},
+			false,
+		},
+		{
+			"empty data lines",
+			"data\ndata: line\ndata\n",
+			&Event{
+				Data: []byte("\nline\n"),
+			},
+			false,
+		},
+		{
+			"colons in values",
+			"event: a:b\ndata: {\"url\":\"http://x:8080/a\"}\nid: 1:2:3\n",
+			&Event{
+				LastEventID: "1:2:3",
+				Type:        "a:b",
+				Data:        []byte(`{"url":"http://x:8080/a"}`),
+			},
+			false,
+		},
+		{
+			"multi-line json",
+			"data: {\ndata:   \"a\": \"b:c\"\ndata: }\n",
+			&Event{
+				Data: []byte("{\n  \"a\": \"b:c\"\n}"),
+			},
+			false,
+		},
+		{
+			"retry",
+			"retry: 5000\ndata: hello\n",
+			&Event{
+				Data:  []byte("hello"),
+				Retry: 5 * time.Second,
+			},
+			false,
+		},
+		{
+			"invalid retry",
+			"retry: 5s\ndata: hello\n",
+			&Event{
+				Data: []byte("hello"),
+			},
+			false,
+		},
 		{
 			"no data",
 			"",
@@ -57,3 +115,120 @@ func Test_readEvent(t *testing.T) {
 		}
 	}
 }
+
+func Test_eventScanner_consumed(t *testing.T) {
+	input := "data: one\n\n: keep-alive\n\ndata: two\n\n"
+	scanner := newEventScanner(strings.NewReader(input))
+
+	for {
+		if _, err := scanner.scanEvent(); err != nil {
+			equals(t, io.EOF, err)
+			break
+		}
+	}
+	equals(t, int64(len(input)), scanner.consumed)
+}
+
+func Test_eventScanner_lineEndings(t *testing.T) {
+	input := "data: crlf\r\n\r\ndata: mixed\r\n\ndata: lf\n\r\ndata: cr\r\rdata: a\r\ndata: b\n\n\n\ndata: last"
+	expected := []string{"data: crlf", "data: mixed", "data: lf", "data: cr", "data: a\ndata: b", "data: last"}
+
+	readers := map[string]io.Reader{
+		"whole":          strings.NewReader(input),
+		"byte at a time": iotest.OneByteReader(strings.NewReader(input)),
+	}
+	for name, r := range readers {
+		scanner := newEventScanner(r)
+		var blocks []string
+		for {
+			block, err := scanner.scanEvent()
+			if err == io.EOF {
+				break
+			}
+			ok(t, err)
+			blocks = append(blocks, string(block))
+		}
+		assert(t, reflect.DeepEqual(expected, blocks), "%s: expected %q, got %q", name, expected, blocks)
+		equals(t, int64(len(input)), scanner.consumed)
+	}
+}
+
+func Test_eventScanner_maxEventSize(t *testing.T) {
+	// every line fits in the buffer, but the event doesn't
+	input := strings.Repeat("data: 0123456789\n", 10) + "\n"
+	scanner := newEventScanner(strings.NewReader("data: small\n\n" + input))
+	scanner.setBufferSizes(16, 64)
+
+	block, err := scanner.scanEvent()
+	ok(t, err)
+	equals(t, "data: small", string(block))
+	_, err = scanner.scanEvent()
+	equals(t, ErrEventTooLarge, err)
+	_, err = scanner.scanEvent()
+	equals(t, ErrEventTooLarge, err)
+}
+
+func Test_eventScanner_byteOrderMark(t *testing.T) {
+	input := "\xEF\xBB\xBFid: 1\ndata: one\n\n\xEF\xBB\xBFdata: two\n\n"
+	scanner := newEventScanner(strings.NewReader(input))
+
+	first, err := scanner.scanEvent()
+	ok(t, err)
+	equals(t, "id: 1\ndata: one", string(first))
+	// only a BOM at the start of the stream is stripped
+	second, err := scanner.scanEvent()
+	ok(t, err)
+	equals(t, "\xEF\xBB\xBFdata: two", string(second))
+	// the BOM still counts towards the offset
+	equals(t, int64(len(input)), scanner.consumed)
+}
+
+func TestUTF8Policy(t *testing.T) {
+	invalid := []byte("data: caf\xE9\n")
+
+	tests := []struct {
+		testname  string
+		policy    UTF8Policy
+		expected  string
+		shouldErr bool
+	}{
+		{"pass through", UTF8PassThrough, "data: caf\xE9\n", false},
+		{"replace", UTF8Replace, "data: caf\uFFFD\n", false},
+		{"error", UTF8Error, "", true},
+	}
+
+	for _, test := range tests {
+		actual, err := test.policy.apply(invalid)
+		if test.shouldErr {
+			equals(t, ErrInvalidUTF8, err)
+			continue
+		}
+		ok(t, err)
+		assert(t, string(actual) == test.expected, "%s: expected %q, got %q", test.testname, test.expected, actual)
+	}
+
+	valid, err := UTF8Error.apply([]byte("data: café\n"))
+	ok(t, err)
+	equals(t, "data: café\n", string(valid))
+}
+
+func Test_readEventInto(t *testing.T) {
+	event := &Event{Data: make([]byte, 0, 64)}
+	capacity := cap(event.Data)
+
+	input := []byte("event: update\nid: 1\ndata: first\n")
+	ok(t, readEventInto(event, input))
+	equals(t, &Event{LastEventID: "1", Type: "update", Data: []byte("first")}, event)
+	equals(t, capacity, cap(event.Data))
+
+	// the data doesn't alias the input
+	copy(input, "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx")
+	equals(t, "first", string(event.Data))
+
+	// fields from the previous event don't leak into the next one
+	ok(t, readEventInto(event, []byte("data: second\n")))
+	equals(t, &Event{Data: []byte("second")}, event)
+	equals(t, capacity, cap(event.Data))
+
+	assert(t, readEventInto(event, nil) != nil, "should've errored but didn't")
+}
@@ -0,0 +1,152 @@
+package sse
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EventIDStore persists the ID of the last event received by streams, so a
+// stream started again, e.g. after the process restarted, resumes where the
+// previous one left off, see Client.EventIDStore. Streams are identified by
+// a key, the URL of their request unless set with WithEventIDKey.
+type EventIDStore interface {
+	// Load returns the last event ID saved for the stream, empty if none was
+	Load(streamKey string) (string, error)
+	// Save saves the ID of the last event received by the stream
+	Save(streamKey, id string) error
+}
+
+type eventIDKey struct{}
+
+// WithEventIDKey returns a copy of ctx carrying key. Streams started from a
+// request with this context are identified by key in the Client's
+// EventIDStore, e.g. when their URL holds a short-lived token.
+func WithEventIDKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, eventIDKey{}, key)
+}
+
+// eventIDStoreKey returns the key of the stream of req in an EventIDStore
+func eventIDStoreKey(req *http.Request) string {
+	if key, ok := req.Context().Value(eventIDKey{}).(string); ok {
+		return key
+	}
+	return req.URL.String()
+}
+
+// loadEventID returns req resuming from the event ID saved for its stream,
+// unless the request sets its own Last-Event-ID. The ID is also kept in
+// state for reconnects.
+func (c *Client) loadEventID(req *http.Request, state *streamState) (*http.Request, error) {
+	if c.EventIDStore == nil || c.DisableLastEventID {
+		return req, nil
+	}
+	state.storeKey = eventIDStoreKey(req)
+	if req.Header.Get("Last-Event-ID") != "" {
+		return req, nil
+	}
+
+	id, err := c.EventIDStore.Load(state.storeKey)
+	if err != nil {
+		return req, fmt.Errorf("loading last event ID: %w", err)
+	}
+	if id == "" {
+		return req, nil
+	}
+	state.lastEventID = id
+	req = cloneRequest(req)
+	req.Header.Set("Last-Event-ID", id)
+	return req, nil
+}
+
+// saveEventID saves the ID of the last event received by the stream
+func (c *Client) saveEventID(state *streamState) error {
+	if c.EventIDStore == nil || state.storeKey == "" {
+		return nil
+	}
+	if err := c.EventIDStore.Save(state.storeKey, state.lastEventID); err != nil {
+		return fmt.Errorf("saving last event ID: %w", err)
+	}
+	return nil
+}
+
+// MemoryEventIDStore is an EventIDStore keeping IDs in memory, so streams of
+// the same process resume across restarts of the stream only
+type MemoryEventIDStore struct {
+	mutex sync.Mutex
+	ids   map[string]string
+}
+
+// NewMemoryEventIDStore creates an empty MemoryEventIDStore
+func NewMemoryEventIDStore() *MemoryEventIDStore {
+	return &MemoryEventIDStore{ids: make(map[string]string)}
+}
+
+// Load implements EventIDStore
+func (s *MemoryEventIDStore) Load(streamKey string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ids[streamKey], nil
+}
+
+// Save implements EventIDStore
+func (s *MemoryEventIDStore) Save(streamKey, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ids[streamKey] = id
+	return nil
+}
+
+// FileEventIDStore is an EventIDStore keeping the ID of every stream in a
+// file of a directory. Files are replaced atomically, so an ID is never
+// partially written.
+type FileEventIDStore struct {
+	dir string
+}
+
+// NewFileEventIDStore creates a FileEventIDStore in dir, which is created
+// if it doesn't exist
+func NewFileEventIDStore(dir string) (*FileEventIDStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileEventIDStore{dir: dir}, nil
+}
+
+// path returns the file of a stream, named after the hash of its key since
+// keys are usually URLs
+func (s *FileEventIDStore) path(streamKey string) string {
+	sum := sha256.Sum256([]byte(streamKey))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// Load implements EventIDStore
+func (s *FileEventIDStore) Load(streamKey string) (string, error) {
+	id, err := os.ReadFile(s.path(streamKey))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(id), err
+}
+
+// Save implements EventIDStore
+func (s *FileEventIDStore) Save(streamKey, id string) error {
+	tmp, err := os.CreateTemp(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(id); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(streamKey))
+}
@@ -0,0 +1,101 @@
+package sse
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventIDStores(t *testing.T) {
+	fileStore, err := NewFileEventIDStore(filepath.Join(t.TempDir(), "ids"))
+	ok(t, err)
+
+	stores := map[string]EventIDStore{
+		"memory": NewMemoryEventIDStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		id, err := store.Load("https://example.com/a")
+		ok(t, err)
+		equals(t, "", id)
+
+		ok(t, store.Save("https://example.com/a", "1"))
+		ok(t, store.Save("https://example.com/a", "2"))
+		ok(t, store.Save("https://example.com/b", "9"))
+		id, err = store.Load("https://example.com/a")
+		ok(t, err)
+		assert(t, id == "2", "%s: expected ID 2, got %q", name, id)
+	}
+
+	// a new store in the same directory resumes where the first one was
+	reopened, err := NewFileEventIDStore(fileStore.dir)
+	ok(t, err)
+	id, err := reopened.Load("https://example.com/b")
+	ok(t, err)
+	equals(t, "9", id)
+}
+
+func TestClient_EventIDStore(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Last-Event-ID")
+		w.Write([]byte("id: 6\ndata: six\n\ndata: no id\n\n"))
+	}))
+	defer server.Close()
+
+	store := NewMemoryEventIDStore()
+	ok(t, store.Save("feed", "5"))
+	c := NewClient(server.Client(), WithEventIDStore(store))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	eventch, errch := c.Stream(req, StreamEventIDKey("feed"))
+	var events []string
+	for r := range collect(eventch, errch) {
+		if r.Event != nil {
+			events = append(events, string(r.Event.Data))
+		}
+	}
+	equals(t, "5", <-received)
+	equals(t, []string{"six", "no id"}, events)
+	id, err := store.Load("feed")
+	ok(t, err)
+	equals(t, "6", id)
+
+	// a Last-Event-ID set by the request wins over the saved one
+	eventch, errch = c.Stream(req, StreamEventIDKey("feed"), StreamHeader("Last-Event-ID", "3"))
+	for range collect(eventch, errch) {
+	}
+	equals(t, "3", <-received)
+}
+
+type failingEventIDStore struct{}
+
+func (failingEventIDStore) Load(string) (string, error) { return "", errors.New("disk on fire") }
+func (failingEventIDStore) Save(string, string) error   { return errors.New("disk on fire") }
+
+func TestClient_EventIDStoreErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("id: 1\ndata: one\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithEventIDStore(failingEventIDStore{}))
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var errs []string
+	var events int
+	for r := range collect(c.Stream(req)) {
+		if r.Err != nil {
+			errs = append(errs, r.Err.Error())
+		} else {
+			events++
+		}
+	}
+	// the stream goes on without the store
+	equals(t, 1, events)
+	equals(t, []string{"loading last event ID: disk on fire", "saving last event ID: disk on fire", ErrStreamIsClosed.Error()}, errs)
+}
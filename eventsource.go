@@ -0,0 +1,204 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// EventSource mirrors the EventSource of the JavaScript WHATWG API, so code
+// written for browsers ports over with few changes and the client behaves as
+// they do. The stream starts as soon as the EventSource is created, and its
+// handlers are called one at a time, in the order their events happened:
+//
+//	source, err := client.EventSource("https://example.com/events")
+//	source.OnMessage(func(event *sse.Event) { fmt.Println(string(event.Data)) })
+//	source.AddEventListener("price", onPrice)
+//
+// Like browsers, an EventSource reconnects after errors if the Client has
+// Reconnect set, as it should. Its ReadyState is then Connecting again until
+// the next connection opens, and Closed once the stream has ended.
+type EventSource struct {
+	url    string
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	state  *stateTracker
+
+	mutex     sync.Mutex
+	onOpen    func()
+	onMessage func(*Event)
+	onError   func(error)
+	listeners map[string][]func(*Event)
+}
+
+// EventSource connects to url, like new EventSource(url) in JavaScript. It
+// returns an error if url isn't a valid request URL.
+func (c *Client) EventSource(url string) (*EventSource, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	es := &EventSource{
+		url:    url,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		state:  newStateTracker(),
+	}
+	// the stream waits for the open handler, so it runs before the events of
+	// the connection
+	opened := make(chan struct{})
+	ctx = WithStreamTrace(withStateTracker(ctx, es.state), &StreamTrace{
+		Connected: func(*http.Response) {
+			select {
+			case opened <- struct{}{}:
+			case <-ctx.Done():
+			}
+		},
+	})
+	eventch, errch, done := c.startStream(req.WithContext(ctx))
+
+	go func() {
+		defer close(es.done)
+		defer es.state.set(Closed)
+		defer cancel()
+
+		// handlers aren't called once closed, though the stream is still
+		// drained until it has ended
+		for {
+			select {
+			case <-opened:
+				if onOpen := es.handlers().onOpen; onOpen != nil && ctx.Err() == nil {
+					onOpen()
+				}
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				if ctx.Err() == nil {
+					es.dispatch(event)
+				}
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				if onError := es.handlers().onError; onError != nil && ctx.Err() == nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return es, nil
+}
+
+// eventSourceHandlers are the handlers of an EventSource at some point
+type eventSourceHandlers struct {
+	onOpen    func()
+	onMessage func(*Event)
+	onError   func(error)
+	listeners []func(*Event)
+}
+
+// handlers returns the handlers registered so far, along with the listeners
+// of eventType
+func (es *EventSource) handlers(eventType ...string) eventSourceHandlers {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	h := eventSourceHandlers{onOpen: es.onOpen, onMessage: es.onMessage, onError: es.onError}
+	for _, t := range eventType {
+		h.listeners = append(h.listeners, es.listeners[t]...)
+	}
+	return h
+}
+
+// dispatch calls the handlers of event, until the EventSource is closed. Events without a type are messages.
+func (es *EventSource) dispatch(event *Event) {
+	eventType := event.Type
+	if eventType == "" {
+		eventType = "message"
+	}
+	h := es.handlers(eventType)
+	if eventType == "message" && h.onMessage != nil {
+		h.onMessage(event)
+	}
+	for _, listener := range h.listeners {
+		if es.ctx.Err() != nil {
+			return
+		}
+		listener(event)
+	}
+}
+
+// OnOpen sets the handler called whenever a connection opens, like onopen
+func (es *EventSource) OnOpen(handler func()) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.onOpen = handler
+}
+
+// OnMessage sets the handler of the events without a type or of type
+// "message", like onmessage
+func (es *EventSource) OnMessage(handler func(*Event)) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.onMessage = handler
+}
+
+// OnError sets the handler called with the errors of the stream, like
+// onerror: those it reconnects after, then the one it ended with. Close
+// doesn't call it.
+func (es *EventSource) OnError(handler func(error)) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.onError = handler
+}
+
+// AddEventListener adds a listener called with the events of eventType, like
+// addEventListener. Listeners of "message" are called after OnMessage's handler.
+func (es *EventSource) AddEventListener(eventType string, listener func(*Event)) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	if es.listeners == nil {
+		es.listeners = make(map[string][]func(*Event))
+	}
+	es.listeners[eventType] = append(es.listeners[eventType], listener)
+}
+
+// ReadyState returns the state of the connection, like readyState: one of
+// Connecting, while waiting to reconnect too, Open and Closed
+func (es *EventSource) ReadyState() ReadyState {
+	if es.ctx.Err() != nil {
+		return Closed
+	}
+	if state := es.state.get(); state != Reconnecting {
+		return state
+	}
+	return Connecting
+}
+
+// URL returns the URL the EventSource was created with, like url
+func (es *EventSource) URL() string {
+	return es.url
+}
+
+// Close stops the stream, like close. Once it has returned, no handler is
+// called but one already running, so it can be called from a handler, e.g.
+// to stop reconnecting after an error.
+func (es *EventSource) Close() error {
+	es.cancel()
+	return nil
+}
+
+// Done returns a channel closed once the stream has ended and its last
+// handler has returned
+func (es *EventSource) Done() <-chan struct{} {
+	return es.done
+}
@@ -0,0 +1,42 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventSource(t *testing.T) {
+	ready := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-ready
+		w.Write([]byte("data: a\n\nevent: ping\ndata: b\n\nevent: message\ndata: c\n\nevent: other\ndata: d\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+	es, err := c.EventSource(server.URL)
+	ok(t, err)
+	equals(t, server.URL, es.URL())
+	equals(t, Connecting, es.ReadyState())
+
+	var calls []string
+	es.OnOpen(func() { calls = append(calls, "open") })
+	es.OnMessage(func(event *Event) { calls = append(calls, "message "+string(event.Data)) })
+	es.AddEventListener("ping", func(event *Event) { calls = append(calls, "ping "+string(event.Data)) })
+	es.OnError(func(err error) {
+		calls = append(calls, "error "+err.Error())
+		// stops reconnecting, no handler is called afterwards
+		es.Close()
+	})
+	close(ready)
+
+	select {
+	case <-es.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("EventSource wasn't closed")
+	}
+	equals(t, []string{"open", "message a", "ping b", "message c", "error " + ErrStreamIsClosed.Error()}, calls)
+	equals(t, Closed, es.ReadyState())
+}
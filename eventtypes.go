@@ -0,0 +1,123 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+// eventTypes is the set of event types a stream reads, see WithEventTypes.
+// It grows as handlers are registered with Stream.On.
+type eventTypes struct {
+	mutex sync.RWMutex
+	types map[string]struct{}
+}
+
+func newEventTypes(types ...string) *eventTypes {
+	t := &eventTypes{types: make(map[string]struct{}, len(types))}
+	t.add(types...)
+	return t
+}
+
+func (t *eventTypes) add(types ...string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, eventType := range types {
+		t.types[eventType] = struct{}{}
+	}
+}
+
+// has reports whether eventType is in the set, without allocating
+func (t *eventTypes) has(eventType []byte) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	_, ok := t.types[string(eventType)]
+	return ok
+}
+
+// clone returns a copy of the set, nil if t is nil
+func (t *eventTypes) clone() *eventTypes {
+	if t == nil {
+		return nil
+	}
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	clone := newEventTypes()
+	for eventType := range t.types {
+		clone.types[eventType] = struct{}{}
+	}
+	return clone
+}
+
+type eventTypesKey struct{}
+
+// WithEventTypes returns a copy of ctx carrying types. Streams started from a
+// request with this context only read the events of these types, the Type
+// they would be delivered with, DefaultEventType included. The blocks of
+// other events are skipped before being parsed, so they cost no allocation,
+// though their id and retry fields still apply. Control and error events,
+// see ControlEventType and ErrorEventTypes, and heartbeats are still read.
+// Types already in ctx are kept.
+func WithEventTypes(ctx context.Context, types ...string) context.Context {
+	set := eventTypesFromContext(ctx).clone()
+	if set == nil {
+		set = newEventTypes()
+	}
+	set.add(types...)
+	return context.WithValue(ctx, eventTypesKey{}, set)
+}
+
+// eventTypesFromContext returns the types stored in ctx by WithEventTypes, or
+// nil if the stream reads every event
+func eventTypesFromContext(ctx context.Context) *eventTypes {
+	types, _ := ctx.Value(eventTypesKey{}).(*eventTypes)
+	return types
+}
+
+// readsEvent reports whether the event of a block is one of types, or is a
+// control or error event, which are always read
+func (c *Client) readsEvent(types *eventTypes, eventBytes []byte) bool {
+	eventType, _ := findField(eventBytes, []byte(eventTypeEvent))
+	if len(eventType) == 0 {
+		eventType = []byte(c.DefaultEventType)
+	}
+	if types.has(eventType) || (c.ControlEventType != "" && string(eventType) == c.ControlEventType) {
+		return true
+	}
+	for _, errorType := range c.ErrorEventTypes {
+		if string(eventType) == errorType {
+			return true
+		}
+	}
+	return false
+}
+
+// skipEvent applies the id and retry fields of a block that isn't read, so
+// skipping it doesn't change where the stream resumes from. It reports
+// whether the last event ID changed.
+func (c *Client) skipEvent(eventBytes []byte, state *streamState) bool {
+	if value, found := findField(eventBytes, []byte(eventTypeRetry)); found {
+		if milliseconds, ok := parseDigits(value); ok && milliseconds > 0 {
+			state.retry = time.Duration(milliseconds) * time.Millisecond
+		}
+	}
+
+	value, found := findField(eventBytes, []byte(eventTypeID))
+	switch {
+	case !found || bytes.IndexByte(value, 0) >= 0:
+		return false
+	case len(value) > 0:
+		if string(value) == state.lastEventID {
+			return false
+		}
+		state.lastEventID = string(value)
+		return true
+	case c.CarryLastEventID:
+		// an empty id field resets the last event ID
+		changed := state.lastEventID != ""
+		state.lastEventID = ""
+		return changed
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_EventTypes(t *testing.T) {
+	lastEventIDs := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Write([]byte("event: price\nid: 1\ndata: 1\n\nevent: trade\nid: 2\ndata: 2\n\nid: 3\ndata: 3\n\nevent: error\ndata: 4\n\nevent: news\nid: 5\nretry: 10\ndata: 5\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.DefaultEventType = "message"
+	c.ErrorEventTypes = []string{"error"}
+	ctx, cancel := context.WithCancel(WithEventTypes(context.Background(), "price"))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+	c.Reconnect = true
+	c.ReconnectDelay = time.Hour
+
+	eventch, errch := c.Stream(req, StreamEventTypes("message"))
+	equals(t, "1", string((<-eventch).Data))
+	equals(t, "3", string((<-eventch).Data))
+	// error events are read whatever their type
+	_, isEventError := (<-errch).(*EventError)
+	assert(t, isEventError, "expected the error event")
+	equals(t, ErrStreamIsClosed, <-errch)
+	equals(t, "", <-lastEventIDs)
+	// the skipped events still count to resume, their retry included
+	equals(t, "5", <-lastEventIDs)
+	cancel()
+	for range collect(eventch, errch) {
+	}
+}
+
+func TestClient_SkipEventAllocs(t *testing.T) {
+	c := NewClient(nil)
+	types := newEventTypes("price")
+	state := &streamState{lastEventID: "42"}
+	block := []byte("event: trade\nid: 42\ndata: {\"price\": 1}\n")
+	allocs := testing.AllocsPerRun(100, func() {
+		if !c.readsEvent(types, block) {
+			c.skipEvent(block, state)
+		}
+	})
+	equals(t, float64(0), allocs)
+}
@@ -0,0 +1,120 @@
+package sse
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Failover rotates reconnecting streams through several endpoints serving
+// the same stream, e.g. the replicas of a highly available server without a
+// load balancer in front, see Client.Failover.
+//
+// The endpoints of a stream are a single list: the URL of its request,
+// followed by the HealthProber's Failover and then by Endpoints, without
+// duplicates. Failing over and probing both go through it in that order.
+// With a HealthProber, the endpoints are probed before every reconnect, so a
+// stream moves to the next healthy endpoint as soon as its current one fails
+// its probe.
+type Failover struct {
+	// Endpoints lists other URLs serving the same stream, in the order they
+	// are failed over to, after those of HealthProber.Failover
+	Endpoints []string
+	// Threshold is the number of consecutive failed connection attempts to
+	// an endpoint after which the stream fails over to the next one, 1 if
+	// zero. The stream still waits its reconnect delay before connecting to
+	// the next endpoint.
+	Threshold int
+	// OnActive, if set, is called with the URL of the endpoint a stream is
+	// about to connect to whenever it changes, including the first one and
+	// redirects asked for by the server
+	OnActive func(req *http.Request, endpoint string)
+}
+
+// endpoints returns the endpoints of the stream of req, without duplicates:
+// its URL, then the HealthProber's Failover, then the Failover's Endpoints
+func (c *Client) endpoints(req *http.Request) []string {
+	endpoints := []string{req.URL.String()}
+	if c.HealthProber != nil {
+		endpoints = appendEndpoints(endpoints, c.HealthProber.Failover...)
+	}
+	if c.Failover != nil {
+		endpoints = appendEndpoints(endpoints, c.Failover.Endpoints...)
+	}
+	return endpoints
+}
+
+// appendEndpoints appends the endpoints that endpoints doesn't hold already
+func appendEndpoints(endpoints []string, more ...string) []string {
+	for _, endpoint := range more {
+		duplicate := false
+		for _, e := range endpoints {
+			duplicate = duplicate || e == endpoint
+		}
+		if !duplicate {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// failover moves req to the next endpoint once the connection attempts to
+// its current one have failed Threshold times in a row. With a HealthProber,
+// the next endpoint passing its probe is picked, skipping those still down.
+func (c *Client) failover(req *http.Request, state *streamState) *http.Request {
+	if c.Failover == nil || len(state.endpoints) < 2 {
+		return req
+	}
+	threshold := c.Failover.Threshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if state.attempts == 0 || state.attempts%threshold != 0 {
+		return req
+	}
+
+	candidates := rotation(req.URL.String(), state.endpoints)
+	target := candidates[0]
+	if c.HealthProber != nil {
+		if healthy, err := c.HealthProber.Probe(req.Context(), c.HTTPClient, candidates...); err == nil {
+			target = healthy
+		}
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return req
+	}
+	// byte offsets don't carry over to another endpoint
+	state.offset = 0
+	return applyControl(req, &Control{Directive: ControlRedirect, URL: u})
+}
+
+// rotation returns the endpoints after current, in rotation order, which
+// starts over from the first one when current isn't one of them, e.g. after
+// a redirect
+func rotation(current string, endpoints []string) []string {
+	next := 0
+	for i, endpoint := range endpoints {
+		if endpoint == current {
+			next = i + 1
+		}
+	}
+	var candidates []string
+	for i := 0; i < len(endpoints); i++ {
+		if candidate := endpoints[(next+i)%len(endpoints)]; candidate != current {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// reportEndpoint calls the OnActive hook of the Failover if req is about to
+// connect to another endpoint than the previous attempt
+func (c *Client) reportEndpoint(req *http.Request, state *streamState) {
+	if c.Failover == nil || c.Failover.OnActive == nil {
+		return
+	}
+	if endpoint := req.URL.String(); endpoint != state.activeEndpoint {
+		state.activeEndpoint = endpoint
+		c.Failover.OnActive(req, endpoint)
+	}
+}
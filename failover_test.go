@@ -0,0 +1,130 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_Failover(t *testing.T) {
+	var mutex sync.Mutex
+	attempts := map[string]int{}
+	down := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				mutex.Lock()
+				attempts[name]++
+				mutex.Unlock()
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	}
+	down1, down2 := down("down1"), down("down2")
+	defer down1.Close()
+	defer down2.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte("data: up\n\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer up.Close()
+
+	tests := []struct {
+		testname string
+		prober   *HealthProber
+		active   []string
+		attempts map[string]int
+	}{
+		{"rotation", nil, []string{down1.URL, down2.URL, up.URL}, map[string]int{"down1": 2, "down2": 2}},
+		{"health aware", &HealthProber{}, []string{down1.URL, up.URL}, map[string]int{"down1": 1}},
+	}
+
+	for _, test := range tests {
+		attempts = map[string]int{}
+		var active []string
+		c := NewClient(http.DefaultClient, WithReconnect(time.Millisecond), WithFailover(2, down2.URL, up.URL))
+		c.Failover.OnActive = func(req *http.Request, endpoint string) {
+			equals(t, endpoint, req.URL.String())
+			active = append(active, endpoint)
+		}
+		c.HealthProber = test.prober
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, down1.URL, nil)
+		ok(t, err)
+
+		eventch, errch := c.Stream(req)
+		go func() {
+			for range errch {
+			}
+		}()
+		select {
+		case event := <-eventch:
+			equals(t, "up", string(event.Data))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: didn't fail over to the endpoint up", test.testname)
+		}
+		cancel()
+
+		equals(t, test.active, active)
+		mutex.Lock()
+		equals(t, test.attempts, attempts)
+		mutex.Unlock()
+	}
+}
+
+func TestClient_FailoverProberEndpoints(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	// passes its probes but rejects the stream
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer rejecting.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte("data: up\n\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer up.Close()
+
+	// the endpoints of the prober come first in the one list both go through
+	c := NewClient(http.DefaultClient, WithReconnect(time.Millisecond), WithFailover(1, up.URL, rejecting.URL))
+	c.HealthProber = &HealthProber{Failover: []string{rejecting.URL}}
+	var active []string
+	c.Failover.OnActive = func(req *http.Request, endpoint string) {
+		active = append(active, endpoint)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, down.URL, nil)
+	ok(t, err)
+	equals(t, []string{down.URL, rejecting.URL, up.URL}, c.endpoints(req))
+
+	eventch, errch := c.Stream(req)
+	go func() {
+		for range errch {
+		}
+	}()
+	select {
+	case event := <-eventch:
+		equals(t, "up", string(event.Data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't fail over to the endpoint up")
+	}
+	cancel()
+
+	equals(t, []string{down.URL, rejecting.URL, up.URL}, active)
+}
@@ -0,0 +1,37 @@
+//go:build js && wasm
+// +build js,wasm
+
+package sse
+
+import (
+	"net/http"
+	"syscall/js"
+)
+
+// In WebAssembly, net/http sends requests with the fetch API of the browser,
+// or of Node.js, and streams their response bodies, so the Client works as
+// elsewhere. The HTTP client must keep a Transport without its own dialer,
+// http.DefaultClient does, since there are no sockets to dial.
+
+// WithFetchCredentials sends requests with the cookies and HTTP
+// authentication of the browser, also to other origins, like the
+// withCredentials option of EventSource. It does nothing outside WebAssembly.
+func WithFetchCredentials() ClientOption {
+	return func(c *Client) {
+		if c.Header == nil {
+			c.Header = http.Header{}
+		}
+		// read by net/http, which sets the option of fetch instead of sending it
+		c.Header.Set("js.fetch:credentials", "include")
+	}
+}
+
+// checkStreaming fails when fetch responses have no body stream, e.g. in old
+// browsers, since net/http then reads the body in full, which an event
+// stream never ends
+func checkStreaming() error {
+	if js.Global().Get("ReadableStream").IsUndefined() {
+		return ErrStreamingUnsupported
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package sse
+
+// WithFetchCredentials sends requests with the cookies and HTTP
+// authentication of the browser in WebAssembly, see its js/wasm version. It
+// does nothing elsewhere.
+func WithFetchCredentials() ClientOption {
+	return func(*Client) {}
+}
+
+// checkStreaming fails when response bodies can't be streamed, which they
+// always can outside WebAssembly
+func checkStreaming() error {
+	return nil
+}
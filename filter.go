@@ -0,0 +1,35 @@
+package sse
+
+import "context"
+
+type eventFilterKey struct{}
+
+// WithEventFilter returns a copy of ctx carrying filter. Streams started from
+// a request with this context only deliver the events filter returns true
+// for, heartbeats included. The others are dropped before reaching the
+// event channel, so the consumer isn't woken for them, though their IDs
+// still count to resume the stream. Filters already in ctx still apply.
+func WithEventFilter(ctx context.Context, filter func(*Event) bool) context.Context {
+	if previous := eventFilterFromContext(ctx); previous != nil {
+		next := filter
+		filter = func(event *Event) bool { return previous(event) && next(event) }
+	}
+	return context.WithValue(ctx, eventFilterKey{}, filter)
+}
+
+// eventFilterFromContext returns the filter stored in ctx by WithEventFilter, or nil
+func eventFilterFromContext(ctx context.Context) func(*Event) bool {
+	filter, _ := ctx.Value(eventFilterKey{}).(func(*Event) bool)
+	return filter
+}
+
+// filterEvents returns emit dropping the events filter rejects
+func filterEvents(emit func(Result), filter func(*Event) bool) func(Result) {
+	return func(r Result) {
+		if r.Event != nil && !filter(r.Event) {
+			r.Event.Release()
+			return
+		}
+		emit(r)
+	}
+}
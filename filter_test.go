@@ -0,0 +1,30 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_EventFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(": keep-alive\n\nevent: price\ndata: 1\n\nevent: trade\ndata: 2\n\nevent: price\ndata: 3\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.HeartbeatOnComment = true
+	ctx := WithEventFilter(context.Background(), func(event *Event) bool { return string(event.Data) != "3" })
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var data []string
+	for r := range collect(c.Stream(req, StreamEventFilter(func(event *Event) bool { return event.Type == "price" }))) {
+		if r.Event != nil {
+			data = append(data, string(r.Event.Data))
+		}
+	}
+	// both filters apply, and heartbeats are filtered too
+	equals(t, []string{"1"}, data)
+}
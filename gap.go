@@ -0,0 +1,46 @@
+package sse
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SequenceGapError is passed through the error channel, ahead of the event
+// it was detected on, when the sequence numbers of event IDs skip some, e.g.
+// because the server couldn't replay the events sent while reconnecting,
+// see Client.SequenceID. The stream goes on.
+type SequenceGapError struct {
+	// Last is the ID of the last event received before the gap
+	Last string
+	// Next is the ID of the event received after the gap
+	Next string
+	// Missed is the number of sequence numbers skipped
+	Missed uint64
+}
+
+func (e *SequenceGapError) Error() string {
+	return fmt.Sprintf("%d events missed between IDs %s and %s", e.Missed, e.Last, e.Next)
+}
+
+// ParseSequenceID parses IDs that are non-negative integers in base 10
+func ParseSequenceID(id string) (uint64, bool) {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	return seq, err == nil
+}
+
+// sequenceGap returns the gap between last, the ID of the last event
+// received, and event, if any
+func (c *Client) sequenceGap(event *Event, last string) *SequenceGapError {
+	if c.SequenceID == nil || event.LastEventID == "" || last == "" {
+		return nil
+	}
+	lastSeq, ok := c.SequenceID(last)
+	if !ok {
+		return nil
+	}
+	nextSeq, ok := c.SequenceID(event.LastEventID)
+	if !ok || nextSeq <= lastSeq+1 {
+		return nil
+	}
+	return &SequenceGapError{Last: last, Next: event.LastEventID, Missed: nextSeq - lastSeq - 1}
+}
@@ -0,0 +1,55 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_sequenceGap(t *testing.T) {
+	c := &Client{SequenceID: ParseSequenceID}
+
+	tests := []struct {
+		testname string
+		last     string
+		next     string
+		expected *SequenceGapError
+	}{
+		{"next in sequence", "4", "5", nil},
+		{"gap", "4", "7", &SequenceGapError{Last: "4", Next: "7", Missed: 2}},
+		{"going back", "4", "2", nil},
+		{"first event", "", "7", nil},
+		{"no ID", "4", "", nil},
+		{"unparseable", "4", "x", nil},
+	}
+
+	for _, test := range tests {
+		actual := c.sequenceGap(&Event{LastEventID: test.next}, test.last)
+		assert(t, (actual == nil) == (test.expected == nil), "%s: expected %v, got %v", test.testname, test.expected, actual)
+		if actual != nil {
+			equals(t, test.expected, actual)
+		}
+	}
+	equals(t, (*SequenceGapError)(nil), (&Client{}).sequenceGap(&Event{LastEventID: "7"}, "4"))
+}
+
+func TestClient_GapDetection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("id: 1\ndata: one\n\nid: 2\ndata: two\n\nid: 5\ndata: five\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithGapDetection(ParseSequenceID))
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var results []string
+	for r := range collect(c.Stream(req)) {
+		if r.Err != nil {
+			results = append(results, r.Err.Error())
+		} else {
+			results = append(results, string(r.Event.Data))
+		}
+	}
+	equals(t, []string{"one", "two", "2 events missed between IDs 2 and 5", "five", ErrStreamIsClosed.Error()}, results)
+}
@@ -1,3 +1,3 @@
 module github.com/mellena1/sse-client-go
 
-go 1.12
+go 1.18
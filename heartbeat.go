@@ -0,0 +1,45 @@
+package sse
+
+import (
+	"bytes"
+	"time"
+)
+
+// HeartbeatEventType is the Type of the synthetic events delivered when
+// heartbeats are enabled on the Client. The leading colon mirrors the comment
+// syntax of the wire format, so it won't be confused with real server events.
+const HeartbeatEventType = ":heartbeat"
+
+// newHeartbeatEvent creates a synthetic heartbeat event
+func newHeartbeatEvent(metadata Metadata) *Event {
+	return &Event{Type: HeartbeatEventType, Metadata: metadata}
+}
+
+// isCommentBlock reports whether every non-empty line of an event block is a comment
+func isCommentBlock(data []byte) bool {
+	sawComment := false
+	for _, line := range bytes.FieldsFunc(data, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if !bytes.HasPrefix(line, []byte(":")) {
+			return false
+		}
+		sawComment = true
+	}
+	return sawComment
+}
+
+// sendHeartbeats emits a heartbeat event every interval until done is closed.
+// Heartbeats go through emit like any other event, so they are queued and
+// observed by the slow consumer detector as well.
+func sendHeartbeats(emit func(Result), interval time.Duration, metadata Metadata, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			emit(Result{Event: newHeartbeatEvent(metadata)})
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_isCommentBlock(t *testing.T) {
+	tests := []struct {
+		testname string
+		input    string
+		expected bool
+	}{
+		{"single comment", ": keep-alive", true},
+		{"many comments", "\n: keep-alive\r\n:ping\n", true},
+		{"comment and data", ": keep-alive\ndata: hello", false},
+		{"only data", "data: hello", false},
+		{"empty", "", false},
+	}
+
+	for _, test := range tests {
+		equals(t, test.expected, isCommentBlock([]byte(test.input)))
+	}
+}
+
+func TestClient_HeartbeatInterval(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	slow := make(chan SlowDelivery, 10)
+	c := NewClient(server.Client())
+	c.HeartbeatInterval = 5 * time.Millisecond
+	c.EventQueueSize = 1
+	c.DeliveryDeadline = time.Millisecond
+	c.OnSlowDelivery = func(s SlowDelivery) {
+		select {
+		case slow <- s:
+		default:
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, _ := c.Stream(req.WithContext(ctx))
+	time.Sleep(50 * time.Millisecond)
+	equals(t, HeartbeatEventType, (<-eventch).Type)
+	// heartbeats are queued like events, so the late one is reported
+	equals(t, HeartbeatEventType, (<-slow).Event.Type)
+}
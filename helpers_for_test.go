@@ -34,3 +34,28 @@ func equals(tb testing.TB, exp, act interface{}) {
 		tb.FailNow()
 	}
 }
+
+// collect merges the channels of a stream until both are closed
+func collect(eventch <-chan *Event, errch <-chan error) <-chan Result {
+	results := make(chan Result)
+	go func() {
+		defer close(results)
+		for eventch != nil || errch != nil {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				results <- Result{Event: event}
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				results <- Result{Err: err}
+			}
+		}
+	}()
+	return results
+}
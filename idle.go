@@ -0,0 +1,47 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamIdle is passed through the error channel when no data, not even a
+// comment, was received within the Client's IdleTimeout. The connection is
+// considered stalled and is closed.
+var ErrStreamIdle = errors.New("no data received from stream within the idle timeout")
+
+// idleReader aborts the connection of a body, with cancel, once nothing was
+// read from it within timeout, so a read blocked on a half-dead connection returns
+type idleReader struct {
+	body    io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	expired int32
+}
+
+func newIdleReader(body io.Reader, timeout time.Duration, cancel func()) *idleReader {
+	r := &idleReader{body: body, timeout: timeout}
+	r.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&r.expired, 1)
+		cancel()
+	})
+	return r
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	if err != nil && atomic.LoadInt32(&r.expired) == 1 {
+		return n, ErrStreamIdle
+	}
+	return n, err
+}
+
+// stop stops watching the body
+func (r *idleReader) stop() {
+	r.timer.Stop()
+}
@@ -0,0 +1,61 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_IdleTimeout(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		// keep-alives hold the connection open past the idle timeout
+		for i := 0; i < 3; i++ {
+			select {
+			case <-time.After(30 * time.Millisecond):
+				w.Write([]byte(": keep-alive\n\n"))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+		// then the connection stalls
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.IdleTimeout = 60 * time.Millisecond
+	c.Reconnect = true
+	c.ReconnectDelay = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	start := time.Now()
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	var data []string
+	for len(data) < 2 {
+		select {
+		case event := <-eventch:
+			// keep-alives are delivered as events without data
+			if len(event.Data) > 0 {
+				data = append(data, string(event.Data))
+			}
+		case err := <-errch:
+			equals(t, ErrStreamIdle, err)
+			equals(t, 1, len(data))
+			assert(t, time.Since(start) >= 90*time.Millisecond, "keep-alives should hold the connection open")
+		}
+	}
+	equals(t, []string{"a", "a"}, data)
+	equals(t, int32(2), atomic.LoadInt32(&connections))
+}
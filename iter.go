@@ -0,0 +1,51 @@
+//go:build go1.23
+// +build go1.23
+
+package sse
+
+import (
+	"context"
+	"iter"
+	"net/http"
+)
+
+// Events streams req and returns an iterator over its events and errors, for
+// ranging over the stream with Go 1.23 or later:
+//
+//	for event, err := range client.Events(ctx, req) {
+//
+// Every iteration has either an event or an error. The iteration ends once the
+// stream has terminated, and breaking out of the loop stops the stream.
+func (c *Client) Events(ctx context.Context, req *http.Request) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		eventch, errch, done := c.startStream(req.WithContext(ctx))
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		for {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				if !yield(event, nil) {
+					return
+				}
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				if !yield(nil, err) {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build go1.23
+// +build go1.23
+
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Events(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var data []string
+	var errs []error
+	for event, err := range NewClient(server.Client()).Events(context.Background(), req) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data = append(data, string(event.Data))
+	}
+	equals(t, []string{"a", "b"}, data)
+	equals(t, []error{ErrStreamIsClosed}, errs)
+}
+
+func TestClient_Events_break(t *testing.T) {
+	disconnected := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(disconnected)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	for event, err := range NewClient(server.Client()).Events(context.Background(), req) {
+		ok(t, err)
+		equals(t, "a", string(event.Data))
+		break
+	}
+	<-disconnected
+}
@@ -0,0 +1,173 @@
+//go:build !tinygo && !sse_minimal
+// +build !tinygo,!sse_minimal
+
+package sse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// This file holds the features relying on encoding/json. They are left out
+// under TinyGo or the sse_minimal build tag, see json_minimal.go.
+
+// errorEventPayload is the json body most APIs use for in-band errors
+type errorEventPayload struct {
+	Code    json.RawMessage `json:"code"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+}
+
+// decodeErrorPayload gets the code and message of a json error payload
+func decodeErrorPayload(data []byte) (code, message string, ok bool) {
+	var payload errorEventPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", "", false
+	}
+
+	message = payload.Message
+	if message == "" {
+		message = payload.Error
+	}
+
+	// codes are commonly either strings or numbers
+	if err := json.Unmarshal(payload.Code, &code); err != nil && len(payload.Code) > 0 && string(payload.Code) != "null" {
+		code = string(payload.Code)
+	}
+
+	return code, message, true
+}
+
+// findJSONPath looks up a dot separated path (e.g. "meta.sent_at") in json data
+// and returns the value found there as a string
+func findJSONPath(data []byte, path string) (string, bool) {
+	var current interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&current); err != nil {
+		return "", false
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = obj[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	default:
+		return "", false
+	}
+}
+
+// JSONCodec is the Codec of JSON payloads, the default of typed decoding
+type JSONCodec struct{}
+
+// Decode unmarshals json data into v
+func (JSONCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Encode marshals v to json
+func (JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// UnmarshalData unmarshals the JSON data of the event into v, see
+// json.Unmarshal. Errors are a *DecodeError naming the event.
+func (e *Event) UnmarshalData(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return &DecodeError{Event: e, Err: err}
+	}
+	return nil
+}
+
+// eventJSON is the JSON schema of an Event, see Event.MarshalJSON
+type eventJSON struct {
+	Type       string     `json:"type,omitempty"`
+	ID         string     `json:"id,omitempty"`
+	Data       *string    `json:"data,omitempty"`
+	DataBase64 []byte     `json:"data_base64,omitempty"`
+	Retry      int64      `json:"retry,omitempty"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+	Metadata   Metadata   `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the event as a JSON object, so it can be persisted or
+// forwarded without losing any of its fields:
+//
+//	{"type": "price", "id": "42", "data": "{\"value\": 1}", "retry": 3000,
+//	 "timestamp": "2024-05-01T12:00:00Z", "metadata": {"source": "eu"}}
+//
+// Data is a string if it is valid UTF-8, else it is encoded in base64 under
+// data_base64 instead. Retry is in milliseconds. Empty fields are left out.
+// This schema is stable, and read back by UnmarshalJSON. The receiver is a
+// value so events are encoded the same whether or not they are pointers.
+func (e Event) MarshalJSON() ([]byte, error) {
+	encoded := eventJSON{
+		Type:     e.Type,
+		ID:       e.LastEventID,
+		Retry:    int64(e.Retry / time.Millisecond),
+		Metadata: e.Metadata,
+	}
+	if utf8.Valid(e.Data) {
+		data := string(e.Data)
+		encoded.Data = &data
+	} else {
+		encoded.DataBase64 = e.Data
+	}
+	if !e.Timestamp.IsZero() {
+		encoded.Timestamp = &e.Timestamp
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes an event encoded by MarshalJSON
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var decoded eventJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Data != nil && decoded.DataBase64 != nil {
+		return errors.New("event has both data and data_base64")
+	}
+
+	*e = Event{
+		LastEventID: decoded.ID,
+		Type:        decoded.Type,
+		Data:        decoded.DataBase64,
+		Retry:       time.Duration(decoded.Retry) * time.Millisecond,
+		Metadata:    decoded.Metadata,
+		pooled:      e.pooled,
+	}
+	if decoded.Data != nil && *decoded.Data != "" {
+		e.Data = []byte(*decoded.Data)
+	}
+	if decoded.Timestamp != nil {
+		e.Timestamp = *decoded.Timestamp
+	}
+	return nil
+}
+
+// defaultCodec is the Codec used when none is configured
+var defaultCodec Codec = JSONCodec{}
+
+// SubscribeJSON is SubscribeCodec with JSONCodec, for the common case of
+// JSON payloads
+func SubscribeJSON[T any](ctx context.Context, client *Client, req *http.Request) (<-chan TypedEvent[T], <-chan error) {
+	return SubscribeCodec[T](ctx, client, req, JSONCodec{})
+}
@@ -0,0 +1,18 @@
+//go:build tinygo || sse_minimal
+// +build tinygo sse_minimal
+
+package sse
+
+// Under TinyGo or the sse_minimal build tag encoding/json is left out to keep
+// the client and decoder small. JSON payloads of error events are delivered
+// as raw messages, TimestampJSONPath is ignored and there is no default Codec.
+
+func decodeErrorPayload(data []byte) (code, message string, ok bool) {
+	return "", "", false
+}
+
+func findJSONPath(data []byte, path string) (string, bool) {
+	return "", false
+}
+
+var defaultCodec Codec
@@ -0,0 +1,86 @@
+//go:build !tinygo && !sse_minimal
+// +build !tinygo,!sse_minimal
+
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_newEventError(t *testing.T) {
+	tests := []struct {
+		testname string
+		data     string
+		code     string
+		message  string
+	}{
+		{"json message and string code", `{"message":"rate limited","code":"too_many"}`, "too_many", "rate limited"},
+		{"json error and number code", `{"error":"not found","code":404}`, "404", "not found"},
+		{"json without code", `{"message":"boom"}`, "", "boom"},
+		{"plain text", "something went wrong", "", "something went wrong"},
+	}
+
+	for _, test := range tests {
+		event := &Event{Type: "error", Data: []byte(test.data)}
+		eventErr := newEventError(event)
+		equals(t, test.code, eventErr.Code)
+		equals(t, test.message, eventErr.Message)
+		equals(t, event, eventErr.Event)
+	}
+}
+
+func Test_setTimestampJSONPath(t *testing.T) {
+	var observed time.Duration
+	c := &Client{
+		TimestampJSONPath: "meta.sent_at",
+		LatencyObserver:   func(_ *Event, latency time.Duration) { observed = latency },
+	}
+	event := &Event{Data: []byte(`{"meta":{"sent_at":"2020-01-02T03:04:05Z"}}`)}
+	c.setTimestamp(event, nil, streamMetrics{})
+	assert(t, event.Timestamp.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), "unexpected timestamp %s", event.Timestamp)
+	assert(t, observed > 0, "latency wasn't observed")
+}
+
+func TestEvent_UnmarshalData(t *testing.T) {
+	var price struct{ Value int }
+	event := &Event{Type: "price", Data: []byte(`{"value": 42}`)}
+	ok(t, event.UnmarshalData(&price))
+	equals(t, 42, price.Value)
+
+	event = &Event{LastEventID: "7", Data: []byte(`{"value":`)}
+	err := event.UnmarshalData(&price)
+	var decodeErr *DecodeError
+	assert(t, errors.As(err, &decodeErr), "expected a DecodeError, got %v", err)
+	equals(t, `decoding message event "7": unexpected end of JSON input`, err.Error())
+}
+
+func TestEvent_MarshalJSON(t *testing.T) {
+	event := &Event{
+		LastEventID: "42",
+		Type:        "price",
+		Data:        []byte(`{"value": 1}`),
+		Retry:       3 * time.Second,
+		Timestamp:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Metadata:    Metadata{"source": "eu"},
+	}
+	encoded, err := json.Marshal(event)
+	ok(t, err)
+	equals(t, `{"type":"price","id":"42","data":"{\"value\": 1}","retry":3000,"timestamp":"2024-05-01T12:00:00Z","metadata":{"source":"eu"}}`, string(encoded))
+
+	var decoded Event
+	ok(t, json.Unmarshal(encoded, &decoded))
+	equals(t, *event, decoded)
+
+	// data that isn't UTF-8 is encoded in base64, and empty fields are left out
+	encoded, err = json.Marshal(Event{Data: []byte{0xff, 0xfe}})
+	ok(t, err)
+	equals(t, `{"data_base64":"//4="}`, string(encoded))
+	decoded = Event{}
+	ok(t, json.Unmarshal(encoded, &decoded))
+	equals(t, Event{Data: []byte{0xff, 0xfe}}, decoded)
+
+	assert(t, json.Unmarshal([]byte(`{"data":"a","data_base64":"YQ=="}`), &decoded) != nil, "expected an error for both data fields")
+}
@@ -0,0 +1,43 @@
+package sse
+
+import (
+	"net/http"
+	"time"
+)
+
+// Logger is told what happens inside the goroutines of streams, including
+// what never surfaces on their error channels, see Client.Logger. Methods
+// are called from the goroutines of the streams, so they must be safe for
+// concurrent use and return quickly. SlogLogger logs to a *slog.Logger.
+type Logger interface {
+	// Connected is called whenever a connection is established
+	Connected(req *http.Request, resp *http.Response)
+	// Disconnected is called with the error a connection attempt ended
+	// with, unless the stream was stopped
+	Disconnected(req *http.Request, err error)
+	// Reconnecting is called before waiting delay to reconnect
+	Reconnecting(req *http.Request, delay time.Duration)
+	// ParseError is called with every event that couldn't be decoded or
+	// applied, such as an invalid control event
+	ParseError(req *http.Request, err error)
+	// DroppedEvent is called with every event dropped by a full event
+	// queue, see OverflowPolicy
+	DroppedEvent(req *http.Request, event *Event)
+}
+
+// nopLogger is the Logger of Clients without one
+type nopLogger struct{}
+
+func (nopLogger) Connected(*http.Request, *http.Response)   {}
+func (nopLogger) Disconnected(*http.Request, error)         {}
+func (nopLogger) Reconnecting(*http.Request, time.Duration) {}
+func (nopLogger) ParseError(*http.Request, error)           {}
+func (nopLogger) DroppedEvent(*http.Request, *Event)        {}
+
+// logger returns the Logger of the Client, which may not be nil
+func (c *Client) logger() Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return nopLogger{}
+}
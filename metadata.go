@@ -0,0 +1,30 @@
+package sse
+
+import "context"
+
+// Metadata is arbitrary key/value data attached to a stream (e.g. tenant,
+// region or subscription name). It is shared by every event of the stream
+// and must not be modified.
+type Metadata map[string]string
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx carrying md. Streams started from a
+// request with this context attach md to every event they deliver, so
+// consumers merging several streams can tell where an event came from.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	merged := Metadata{}
+	for k, v := range MetadataFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range md {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// MetadataFromContext returns the metadata stored in ctx by WithMetadata, or nil
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
@@ -0,0 +1,14 @@
+package sse
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithMetadata(t *testing.T) {
+	ctx := WithMetadata(context.Background(), Metadata{"tenant": "a", "region": "us"})
+	ctx = WithMetadata(ctx, Metadata{"region": "eu"})
+
+	equals(t, Metadata{"tenant": "a", "region": "eu"}, MetadataFromContext(ctx))
+	equals(t, Metadata(nil), MetadataFromContext(context.Background()))
+}
@@ -0,0 +1,314 @@
+package sse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the streams of a Client, see
+// Client.Metrics. Every measurement is labelled with the stream it was taken
+// on, see WithMetricsLabel. Methods are called from the goroutines of the
+// streams, so they must be safe for concurrent use and return quickly.
+//
+// MetricsCollector is an implementation keeping them in memory, which the
+// sseprom module exports as a prometheus.Collector.
+type Metrics interface {
+	// Connected is called whenever a connection is established, with the
+	// time it took to get its response
+	Connected(stream string, latency time.Duration)
+	// Reconnecting is called before every reconnect with the error that
+	// ended the previous connection attempt
+	Reconnecting(stream string, err error)
+	// BytesRead is called with the number of bytes of every read from a
+	// connection
+	BytesRead(stream string, n int)
+	// EventReceived is called with every event delivered
+	EventReceived(stream string, event *Event)
+	// EventLatency is called with the end to end latency of every event
+	// that has a Timestamp, see Client.TimestampField
+	EventLatency(stream string, latency time.Duration)
+}
+
+type metricsLabelKey struct{}
+
+// WithMetricsLabel returns a copy of ctx carrying label. Streams started from
+// a request with this context are labelled with it in their Metrics instead
+// of the host and path of their URL.
+func WithMetricsLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, metricsLabelKey{}, label)
+}
+
+// metricsLabel returns the label of the stream of req in its Metrics
+func metricsLabel(req *http.Request) string {
+	if label, ok := req.Context().Value(metricsLabelKey{}).(string); ok {
+		return label
+	}
+	return req.URL.Host + req.URL.Path
+}
+
+// streamMetrics reports the measurements of a stream to the Client's
+// Metrics, doing nothing if there are none
+type streamMetrics struct {
+	metrics Metrics
+	label   string
+}
+
+// streamMetrics returns the metrics of the stream of req, which also go to
+// the collector of its Stream handle, if any
+func (c *Client) streamMetrics(req *http.Request) streamMetrics {
+	metrics := c.Metrics
+	if collector := streamCollectorFromContext(req.Context()); collector != nil {
+		if metrics == nil {
+			metrics = collector
+		} else {
+			metrics = multiMetrics{metrics, collector}
+		}
+	}
+	if metrics == nil {
+		return streamMetrics{}
+	}
+	return streamMetrics{metrics: metrics, label: metricsLabel(req)}
+}
+
+type streamCollectorKey struct{}
+
+// withStreamCollector returns a copy of ctx carrying collector, so the
+// stream started from a request with this context reports to it too
+func withStreamCollector(ctx context.Context, collector *MetricsCollector) context.Context {
+	return context.WithValue(ctx, streamCollectorKey{}, collector)
+}
+
+// streamCollectorFromContext returns the collector stored in ctx, or nil
+func streamCollectorFromContext(ctx context.Context) *MetricsCollector {
+	collector, _ := ctx.Value(streamCollectorKey{}).(*MetricsCollector)
+	return collector
+}
+
+// multiMetrics reports every measurement to each of its Metrics
+type multiMetrics []Metrics
+
+func (m multiMetrics) Connected(stream string, latency time.Duration) {
+	for _, metrics := range m {
+		metrics.Connected(stream, latency)
+	}
+}
+
+func (m multiMetrics) Reconnecting(stream string, err error) {
+	for _, metrics := range m {
+		metrics.Reconnecting(stream, err)
+	}
+}
+
+func (m multiMetrics) BytesRead(stream string, n int) {
+	for _, metrics := range m {
+		metrics.BytesRead(stream, n)
+	}
+}
+
+func (m multiMetrics) EventReceived(stream string, event *Event) {
+	for _, metrics := range m {
+		metrics.EventReceived(stream, event)
+	}
+}
+
+func (m multiMetrics) EventLatency(stream string, latency time.Duration) {
+	for _, metrics := range m {
+		metrics.EventLatency(stream, latency)
+	}
+}
+
+func (m streamMetrics) connected(latency time.Duration) {
+	if m.metrics != nil {
+		m.metrics.Connected(m.label, latency)
+	}
+}
+
+func (m streamMetrics) reconnecting(err error) {
+	if m.metrics != nil {
+		m.metrics.Reconnecting(m.label, err)
+	}
+}
+
+func (m streamMetrics) eventReceived(event *Event) {
+	if m.metrics != nil {
+		m.metrics.EventReceived(m.label, event)
+	}
+}
+
+func (m streamMetrics) eventLatency(latency time.Duration) {
+	if m.metrics != nil {
+		m.metrics.EventLatency(m.label, latency)
+	}
+}
+
+// body counts the bytes read from body, if there are metrics
+func (m streamMetrics) body(body io.Reader) io.Reader {
+	if m.metrics == nil {
+		return body
+	}
+	return countingReader{r: body, metrics: m}
+}
+
+// countingReader reports the bytes read from a connection to its metrics
+type countingReader struct {
+	r       io.Reader
+	metrics streamMetrics
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.metrics.metrics.BytesRead(r.metrics.label, n)
+	}
+	return n, err
+}
+
+// DefaultLatencyBuckets are the upper bounds of the latency histograms of a
+// MetricsCollector created without buckets
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Histogram is a distribution of latencies
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets, in increasing order
+	Buckets []time.Duration
+	// Counts are the cumulative number of observations of each bucket: the
+	// number of latencies up to its upper bound
+	Counts []uint64
+	// Count is the number of observations
+	Count uint64
+	// Sum is the sum of the latencies observed
+	Sum time.Duration
+}
+
+func newHistogram(buckets []time.Duration) Histogram {
+	return Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) observe(latency time.Duration) {
+	for i := sort.Search(len(h.Buckets), func(i int) bool { return latency <= h.Buckets[i] }); i < len(h.Buckets); i++ {
+		h.Counts[i]++
+	}
+	h.Count++
+	h.Sum += latency
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// StreamStats are the measurements of a stream kept by a MetricsCollector
+type StreamStats struct {
+	Events     uint64
+	Bytes      uint64
+	Connects   uint64
+	Reconnects uint64
+	// LastEvent is the time the last event was received, zero if none was
+	LastEvent time.Time
+	// ConnectLatency is the distribution of the time connections took to
+	// get their response
+	ConnectLatency Histogram
+	// EventLatency is the distribution of the end to end latency of events
+	EventLatency Histogram
+	// Backoff is the delay of the reconnect the stream is waiting for, zero
+	// otherwise. It is only known to Stream.Stats.
+	Backoff time.Duration
+}
+
+// SinceLastEvent returns how long ago the last event was received, or zero
+// if none was
+func (s StreamStats) SinceLastEvent() time.Duration {
+	if s.LastEvent.IsZero() {
+		return 0
+	}
+	return time.Since(s.LastEvent)
+}
+
+// MetricsCollector is a Metrics keeping counters and latency histograms of
+// every stream in memory
+type MetricsCollector struct {
+	buckets []time.Duration
+
+	mutex   sync.Mutex
+	streams map[string]*StreamStats
+	now     func() time.Time
+}
+
+// NewMetricsCollector creates a MetricsCollector with latency histograms of
+// buckets, DefaultLatencyBuckets if none are given
+func NewMetricsCollector(buckets ...time.Duration) *MetricsCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return &MetricsCollector{buckets: buckets, streams: make(map[string]*StreamStats), now: time.Now}
+}
+
+// Stats returns a copy of the measurements of every stream, by label
+func (m *MetricsCollector) Stats() map[string]StreamStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := make(map[string]StreamStats, len(m.streams))
+	for label, s := range m.streams {
+		copied := *s
+		copied.ConnectLatency = s.ConnectLatency.clone()
+		copied.EventLatency = s.EventLatency.clone()
+		stats[label] = copied
+	}
+	return stats
+}
+
+// update calls f with the stats of stream, with the mutex held
+func (m *MetricsCollector) update(stream string, f func(s *StreamStats)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, ok := m.streams[stream]
+	if !ok {
+		s = &StreamStats{ConnectLatency: newHistogram(m.buckets), EventLatency: newHistogram(m.buckets)}
+		m.streams[stream] = s
+	}
+	f(s)
+}
+
+// Connected implements Metrics
+func (m *MetricsCollector) Connected(stream string, latency time.Duration) {
+	m.update(stream, func(s *StreamStats) {
+		s.Connects++
+		s.ConnectLatency.observe(latency)
+	})
+}
+
+// Reconnecting implements Metrics
+func (m *MetricsCollector) Reconnecting(stream string, err error) {
+	m.update(stream, func(s *StreamStats) { s.Reconnects++ })
+}
+
+// BytesRead implements Metrics
+func (m *MetricsCollector) BytesRead(stream string, n int) {
+	m.update(stream, func(s *StreamStats) { s.Bytes += uint64(n) })
+}
+
+// EventReceived implements Metrics
+func (m *MetricsCollector) EventReceived(stream string, event *Event) {
+	now := m.now()
+	m.update(stream, func(s *StreamStats) {
+		s.Events++
+		s.LastEvent = now
+	})
+}
+
+// EventLatency implements Metrics
+func (m *MetricsCollector) EventLatency(stream string, latency time.Duration) {
+	m.update(stream, func(s *StreamStats) { s.EventLatency.observe(latency) })
+}
@@ -0,0 +1,98 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond})
+	for _, latency := range []time.Duration{time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond, time.Second} {
+		h.observe(latency)
+	}
+	equals(t, []uint64{2, 3}, h.Counts)
+	equals(t, uint64(4), h.Count)
+	equals(t, 1061*time.Millisecond, h.Sum)
+}
+
+func TestMetricsCollector(t *testing.T) {
+	m := NewMetricsCollector(100*time.Millisecond, 10*time.Millisecond)
+	now := time.Unix(100, 0)
+	m.now = func() time.Time { return now }
+
+	m.Connected("a", 20*time.Millisecond)
+	m.BytesRead("a", 12)
+	m.BytesRead("a", 3)
+	m.EventReceived("a", &Event{})
+	m.EventLatency("a", 5*time.Millisecond)
+	m.Reconnecting("a", ErrStreamIsClosed)
+	m.Connected("b", 5*time.Millisecond)
+
+	stats := m.Stats()
+	equals(t, 2, len(stats))
+	a := stats["a"]
+	equals(t, uint64(1), a.Events)
+	equals(t, uint64(15), a.Bytes)
+	equals(t, uint64(1), a.Connects)
+	equals(t, uint64(1), a.Reconnects)
+	equals(t, now, a.LastEvent)
+	equals(t, Histogram{Buckets: []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}, Counts: []uint64{0, 1}, Count: 1, Sum: 20 * time.Millisecond}, a.ConnectLatency)
+	equals(t, []uint64{1, 1}, a.EventLatency.Counts)
+	equals(t, time.Duration(0), stats["b"].SinceLastEvent())
+
+	// the stats returned are copies
+	a.ConnectLatency.Counts[0] = 7
+	equals(t, []uint64{0, 1}, m.Stats()["a"].ConnectLatency.Counts)
+}
+
+func TestClient_Metrics(t *testing.T) {
+	const eventsPerConnection = 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < eventsPerConnection; i++ {
+			w.Write([]byte("ts: " + strconv.FormatInt(time.Now().Unix(), 10) + "\ndata: hi\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	metrics := NewMetricsCollector()
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond), WithMetrics(metrics))
+	c.TimestampField = "ts"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+	eventch, errch := c.Stream(req, StreamMetricsLabel("feed"))
+
+	received := 0
+	for r := range collect(eventch, errch) {
+		if r.Event != nil {
+			received++
+			if received == eventsPerConnection+1 {
+				cancel()
+			}
+		}
+	}
+
+	stats := metrics.Stats()
+	equals(t, 1, len(stats))
+	feed := stats["feed"]
+	assert(t, feed.Events >= eventsPerConnection+1, "expected at least %d events, got %d", eventsPerConnection+1, feed.Events)
+	assert(t, feed.Connects >= 2, "expected at least 2 connects, got %d", feed.Connects)
+	assert(t, feed.Reconnects >= 1, "expected a reconnect, got %d", feed.Reconnects)
+	assert(t, feed.Bytes >= feed.Events*uint64(len("ts: 1600000000\ndata: hi\n\n")), "got %d bytes for %d events", feed.Bytes, feed.Events)
+	equals(t, feed.Events, feed.EventLatency.Count)
+	equals(t, feed.Connects, feed.ConnectLatency.Count)
+	assert(t, !feed.LastEvent.IsZero(), "last event time not set")
+}
+
+func Test_metricsLabel(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/events?token=secret", nil)
+	ok(t, err)
+	equals(t, "example.com/events", metricsLabel(req))
+	equals(t, "orders", metricsLabel(req.WithContext(WithMetricsLabel(req.Context(), "orders"))))
+}
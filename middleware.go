@@ -0,0 +1,72 @@
+package sse
+
+import "context"
+
+// EventMiddleware transforms an event before it is delivered, e.g. to
+// decompress or decrypt its data or strip an envelope. It returns the event
+// to pass on, which may be the one it was given, or nil to drop it. An error
+// drops the event and is passed through the error channel as a
+// *TransformError, without ending the stream. Pooled events dropped either
+// way are released.
+type EventMiddleware func(*Event) (*Event, error)
+
+// TransformError is passed through the error channel when an EventMiddleware
+// fails to transform an event
+type TransformError struct {
+	// Event is the event as received, a copy of it if it was pooled
+	Event *Event
+	Err   error
+}
+
+func (e *TransformError) Error() string {
+	return "transforming event: " + e.Err.Error()
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+type eventMiddlewareKey struct{}
+
+// WithEventMiddleware returns a copy of ctx carrying middlewares. Streams
+// started from a request with this context pass every event through them in
+// order before delivering it, and before any filter of WithEventFilter.
+// Middlewares already in ctx run first. Synthetic heartbeat events skip them.
+func WithEventMiddleware(ctx context.Context, middlewares ...EventMiddleware) context.Context {
+	chain := append(append([]EventMiddleware(nil), eventMiddlewareFromContext(ctx)...), middlewares...)
+	return context.WithValue(ctx, eventMiddlewareKey{}, chain)
+}
+
+// eventMiddlewareFromContext returns the middlewares stored in ctx by WithEventMiddleware
+func eventMiddlewareFromContext(ctx context.Context) []EventMiddleware {
+	chain, _ := ctx.Value(eventMiddlewareKey{}).([]EventMiddleware)
+	return chain
+}
+
+// transformEvents returns emit passing events through middlewares first
+func transformEvents(emit func(Result), middlewares []EventMiddleware) func(Result) {
+	return func(r Result) {
+		if r.Event == nil || r.Event.Type == HeartbeatEventType {
+			emit(r)
+			return
+		}
+		event := r.Event
+		for _, middleware := range middlewares {
+			var err error
+			if event, err = middleware(event); err != nil {
+				failed := r.Event
+				if failed.pooled {
+					failed = failed.Clone()
+					r.Event.Release()
+				}
+				emit(Result{Err: &TransformError{Event: failed, Err: err}})
+				return
+			}
+			if event == nil {
+				r.Event.Release()
+				return
+			}
+		}
+		emit(Result{Event: event})
+	}
+}
@@ -0,0 +1,78 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_EventMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {a}\n\ndata: skip\n\ndata: bad\n\ndata: {b}\n\n"))
+	}))
+	defer server.Close()
+
+	errBad := errors.New("bad event")
+	strip := func(event *Event) (*Event, error) {
+		switch string(event.Data) {
+		case "skip":
+			return nil, nil
+		case "bad":
+			return nil, errBad
+		}
+		event.Data = bytes.Trim(event.Data, "{}")
+		return event, nil
+	}
+	upper := func(event *Event) (*Event, error) {
+		event.Data = bytes.ToUpper(event.Data)
+		return event, nil
+	}
+
+	c := NewClient(server.Client())
+	ctx := WithEventMiddleware(context.Background(), strip)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var data []string
+	var transformErr *TransformError
+	for r := range collect(c.Stream(req, StreamEventMiddleware(upper))) {
+		if r.Event != nil {
+			data = append(data, string(r.Event.Data))
+		} else if errors.As(r.Err, &transformErr) {
+			assert(t, errors.Is(r.Err, errBad), "expected the middleware's error, got %v", r.Err)
+		}
+	}
+	// middlewares in ctx run first, dropped and failed events are skipped
+	equals(t, []string{"A", "B"}, data)
+	assert(t, transformErr != nil, "expected a TransformError")
+	equals(t, "bad", string(transformErr.Event.Data))
+}
+
+func Test_transformEventsReleases(t *testing.T) {
+	errBad := errors.New("bad event")
+	var results []Result
+	emit := transformEvents(func(r Result) { results = append(results, r) }, []EventMiddleware{
+		func(event *Event) (*Event, error) {
+			if string(event.Data) == "skip" {
+				return nil, nil
+			}
+			return nil, errBad
+		},
+	})
+
+	// pooled events are released whether a middleware drops them or fails
+	for _, data := range []string{"skip", "bad"} {
+		event := getEvent()
+		ok(t, readEventInto(event, []byte("data: "+data)))
+		emit(Result{Event: event})
+		assert(t, !event.pooled, "expected the %s event to be released", data)
+	}
+
+	equals(t, 1, len(results))
+	var transformErr *TransformError
+	assert(t, errors.As(results[0].Err, &transformErr), "expected a TransformError, got %v", results[0].Err)
+	equals(t, "bad", string(transformErr.Event.Data))
+}
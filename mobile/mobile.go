@@ -0,0 +1,151 @@
+// Package mobile wraps the client in a callback based API, without channels,
+// function values or generics, so iOS and Android apps can consume streams
+// through gomobile bind:
+//
+//	gomobile bind -target android github.com/mellena1/sse-client-go/mobile
+//
+// The app implements Handler and connects with it:
+//
+//	client := mobile.NewClient()
+//	client.SetHeader("Authorization", "Bearer "+token)
+//	stream, err := client.Connect("https://example.com/events", handler)
+//	...
+//	stream.Close()
+package mobile
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// Handler is implemented by the app to follow a stream. Its methods are
+// called one at a time, in the order things happened on the stream.
+type Handler interface {
+	// OnOpen is called whenever a connection opens
+	OnOpen()
+	// OnEvent is called with every event
+	OnEvent(event *Event)
+	// OnError is called with every error of the stream, the ones it
+	// reconnects after included
+	OnError(message string)
+	// OnClose is called once the stream has ended, after everything else
+	OnClose()
+}
+
+// Event is an event received from a stream
+type Event struct {
+	Type string
+	ID   string
+	Data string
+}
+
+// Client connects to streams. It is configured before connecting.
+type Client struct {
+	mutex     sync.Mutex
+	header    http.Header
+	reconnect bool
+	delay     time.Duration
+}
+
+// NewClient returns a Client reconnecting streams after errors, with the
+// delay asked for by the server, or else one of 3 seconds like browsers
+func NewClient() *Client {
+	return &Client{header: http.Header{}, reconnect: true, delay: 3 * time.Second}
+}
+
+// SetHeader sets a header sent with the requests of the streams connected
+// afterwards, e.g. Authorization
+func (c *Client) SetHeader(key, value string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.header.Set(key, value)
+}
+
+// SetReconnect sets whether the streams connected afterwards reconnect after
+// errors, and the delay in milliseconds before they do if the server doesn't
+// ask for one
+func (c *Client) SetReconnect(reconnect bool, delayMillis int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reconnect = reconnect
+	c.delay = time.Duration(delayMillis) * time.Millisecond
+}
+
+// Connect starts streaming url, following it with handler until the stream
+// is closed. It returns an error if url isn't a valid request URL.
+func (c *Client) Connect(url string, handler Handler) (*Stream, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	client := sse.NewClient(http.DefaultClient)
+	client.Header = c.header.Clone()
+	client.Reconnect = c.reconnect
+	client.ReconnectDelay = c.delay
+	c.mutex.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// the stream waits for OnOpen to be called, so it comes before the
+	// events of the connection
+	opened := make(chan struct{})
+	ctx = sse.WithStreamTrace(ctx, &sse.StreamTrace{
+		Connected: func(*http.Response) {
+			select {
+			case opened <- struct{}{}:
+			case <-ctx.Done():
+			}
+		},
+	})
+	results := client.StreamResults(req.WithContext(ctx))
+
+	s := &Stream{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer handler.OnClose()
+
+		for {
+			select {
+			case <-opened:
+				handler.OnOpen()
+			case result, open := <-results:
+				if !open {
+					return
+				}
+				if result.Err != nil {
+					handler.OnError(result.Err.Error())
+					continue
+				}
+				handler.OnEvent(&Event{
+					Type: result.Event.Type,
+					ID:   result.Event.LastEventID,
+					Data: string(result.Event.Data),
+				})
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// Stream is a stream followed by a Handler
+type Stream struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Close stops the stream. The Handler's OnClose is called once it has ended.
+// It can be called from the Handler.
+func (s *Stream) Close() {
+	s.cancel()
+}
+
+// Wait blocks until the stream has ended and OnClose has returned
+func (s *Stream) Wait() {
+	<-s.done
+}
@@ -0,0 +1,46 @@
+package mobile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recorder is a Handler recording its calls
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) OnOpen() { r.calls = append(r.calls, "open") }
+func (r *recorder) OnEvent(event *Event) {
+	r.calls = append(r.calls, event.Type+" "+event.ID+" "+event.Data)
+}
+func (r *recorder) OnClose()               { r.calls = append(r.calls, "close") }
+func (r *recorder) OnError(message string) { r.calls = append(r.calls, "error "+message) }
+
+func TestClient_Connect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("id: 1\ndata: a\n\nevent: price\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.SetReconnect(false, 0)
+	client.SetHeader("Authorization", "Bearer token")
+	handler := &recorder{}
+	stream, err := client.Connect(server.URL, handler)
+	ok(t, err)
+	stream.Wait()
+
+	equals(t, []string{"open", " 1 a", "price  b", "error Stream has closed", "close"}, handler.calls)
+}
+
+func TestClient_Connect_invalidURL(t *testing.T) {
+	_, err := NewClient().Connect("://", &recorder{})
+	assert(t, err != nil, "expected an invalid URL error")
+}
@@ -0,0 +1,196 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// SourceMetadataKey is the metadata key holding the source name of events
+// delivered by a Multiplexer
+const SourceMetadataKey = "source"
+
+// ErrMultiplexerClosed is returned when adding a source to a closed Multiplexer
+var ErrMultiplexerClosed = errors.New("multiplexer is closed")
+
+// Multiplexer streams from several requests concurrently and merges their
+// events into one channel, e.g. to consume an SSE backend sharded by URLs
+// that don't follow a template, see SubscribeSharded otherwise. Each source
+// is a separate stream of the Client, so sources reconnect independently
+// when the Client has Reconnect set.
+type Multiplexer struct {
+	client *Client
+	ctx    context.Context
+
+	mutex   sync.Mutex
+	sources map[string]*sourceStream
+	closed  bool
+	wg      sync.WaitGroup
+
+	events chan *Event
+	errors chan error
+}
+
+// sourceStream is a running stream of a single source
+type sourceStream struct {
+	cancel context.CancelFunc
+	// done is closed once the source's goroutine has exited
+	done chan struct{}
+}
+
+// Multiplex creates a Multiplexer without sources, see Multiplexer.Add.
+// Every source is stopped once ctx is done.
+func (c *Client) Multiplex(ctx context.Context) *Multiplexer {
+	return &Multiplexer{
+		client:  c,
+		ctx:     ctx,
+		sources: make(map[string]*sourceStream),
+		events:  make(chan *Event),
+		errors:  make(chan error),
+	}
+}
+
+// Events returns the merged events of all sources. Every event has the name
+// of its source in its Metadata under SourceMetadataKey.
+func (m *Multiplexer) Events() <-chan *Event {
+	return m.events
+}
+
+// Errors returns the errors of all sources. Errors of a source are wrapped in a *SourceError.
+func (m *Multiplexer) Errors() <-chan error {
+	return m.errors
+}
+
+// SourceError is an error from the stream of a single source of a Multiplexer
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return "source " + e.Source + ": " + e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Add starts streaming req as source, a name unique among the sources of the
+// Multiplexer. The stream keeps the context of req, so per-stream settings
+// such as WithBackpressure apply, and is also stopped with it.
+func (m *Multiplexer) Add(source string, req *http.Request) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return ErrMultiplexerClosed
+	}
+	if _, ok := m.sources[source]; ok {
+		return fmt.Errorf("source %q is already streaming", source)
+	}
+
+	ctx, cancel := context.WithCancel(WithMetadata(req.Context(), Metadata{SourceMetadataKey: source}))
+	stream := &sourceStream{cancel: cancel, done: make(chan struct{})}
+	m.sources[source] = stream
+
+	eventch, errch, done := m.client.startStream(req.WithContext(ctx))
+	m.wg.Add(1)
+	go m.forward(ctx, source, stream, eventch, errch, done)
+	return nil
+}
+
+// Remove stops streaming source and waits for its stream to end. It returns
+// false if there was no such source.
+func (m *Multiplexer) Remove(source string) bool {
+	m.mutex.Lock()
+	stream, ok := m.sources[source]
+	delete(m.sources, source)
+	m.mutex.Unlock()
+
+	if !ok {
+		return false
+	}
+	// the forwarding goroutine takes the mutex once done, so it is waited
+	// for without holding it
+	stream.cancel()
+	<-stream.done
+	return true
+}
+
+// Sources returns the names of the sources streaming, sorted. Sources whose
+// stream has ended, e.g. without Reconnect set, are no longer listed.
+func (m *Multiplexer) Sources() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	sources := make([]string, 0, len(m.sources))
+	for source := range m.sources {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Close stops every source and waits for their streams to end. The event and
+// error channels are closed afterwards.
+func (m *Multiplexer) Close() {
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return
+	}
+	m.closed = true
+	for _, stream := range m.sources {
+		stream.cancel()
+	}
+	m.mutex.Unlock()
+
+	m.wg.Wait()
+	close(m.events)
+	close(m.errors)
+}
+
+// forward passes the events and errors of a source on until its stream ends
+func (m *Multiplexer) forward(ctx context.Context, source string, stream *sourceStream, eventch <-chan *Event, errch <-chan error, done <-chan struct{}) {
+	defer m.wg.Done()
+	defer close(stream.done)
+	defer func() {
+		m.mutex.Lock()
+		if m.sources[source] == stream {
+			delete(m.sources, source)
+		}
+		m.mutex.Unlock()
+	}()
+
+	parentDone := m.ctx.Done()
+	for {
+		select {
+		case event, open := <-eventch:
+			if !open {
+				eventch = nil
+				continue
+			}
+			select {
+			case m.events <- event:
+			case <-ctx.Done():
+			}
+		case err, open := <-errch:
+			if !open {
+				errch = nil
+				continue
+			}
+			select {
+			case m.errors <- &SourceError{Source: source, Err: err}:
+			case <-ctx.Done():
+			}
+		case <-parentDone:
+			stream.cancel()
+			parentDone = nil
+		case <-done:
+			return
+		}
+	}
+}
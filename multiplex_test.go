@@ -0,0 +1,65 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestMultiplexer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("data: " + r.URL.Path + "\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	mux := NewClient(server.Client()).Multiplex(context.Background())
+	for _, source := range []string{"a", "b"} {
+		req, err := http.NewRequestWithContext(WithMetadata(context.Background(), Metadata{"tenant": "t"}), http.MethodGet, server.URL+"/"+source, nil)
+		ok(t, err)
+		ok(t, mux.Add(source, req))
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	ok(t, err)
+	ok(t, mux.Add("missing", req))
+	assert(t, mux.Add("a", req) != nil, "expected adding a source twice to fail")
+
+	var events []string
+	for i := 0; i < 2; i++ {
+		event := <-mux.Events()
+		equals(t, "/"+event.Metadata[SourceMetadataKey], string(event.Data))
+		equals(t, "t", event.Metadata["tenant"])
+		events = append(events, string(event.Data))
+	}
+	sort.Strings(events)
+	equals(t, []string{"/a", "/b"}, events)
+
+	err = <-mux.Errors()
+	var sourceErr *SourceError
+	assert(t, errors.As(err, &sourceErr), "expected a *SourceError, got %T", err)
+	equals(t, "missing", sourceErr.Source)
+	statusCode, _ := StatusCode(err)
+	equals(t, http.StatusNotFound, statusCode)
+
+	assert(t, mux.Remove("a"), "expected source a to be removed")
+	assert(t, !mux.Remove("a"), "expected source a to be removed already")
+	for _, source := range mux.Sources() {
+		assert(t, source == "b" || source == "missing", "unexpected source %s", source)
+	}
+
+	mux.Close()
+	_, open := <-mux.Events()
+	assert(t, !open, "expected the events to be closed")
+	_, open = <-mux.Errors()
+	assert(t, !open, "expected the errors to be closed")
+	equals(t, ErrMultiplexerClosed, mux.Add("c", req))
+	mux.Close()
+}
@@ -0,0 +1,63 @@
+package sse
+
+import "sync"
+
+// EventHandlerFunc handles an event routed to it by an EventMux
+type EventHandlerFunc func(event *Event)
+
+// EventMux routes events to the handler registered for their type, in the
+// manner of http.ServeMux. Events of a type without a handler go to the
+// default handler, if any, and are dropped otherwise. It is safe to register
+// handlers while events are being dispatched.
+type EventMux struct {
+	mutex    sync.RWMutex
+	handlers map[string]EventHandlerFunc
+	fallback EventHandlerFunc
+}
+
+// NewEventMux creates an EventMux without handlers
+func NewEventMux() *EventMux {
+	return &EventMux{handlers: make(map[string]EventHandlerFunc)}
+}
+
+// HandleEvent registers the handler of events of eventType, replacing any
+// previous one. Events without a type are of type "message".
+func (m *EventMux) HandleEvent(eventType string, handler EventHandlerFunc) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.handlers[eventType] = handler
+}
+
+// HandleDefault registers the handler of events of a type without a handler
+func (m *EventMux) HandleDefault(handler EventHandlerFunc) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.fallback = handler
+}
+
+// Dispatch calls the handler of event's type
+func (m *EventMux) Dispatch(event *Event) {
+	eventType := event.Type
+	if eventType == "" {
+		eventType = MessageEventType
+	}
+
+	m.mutex.RLock()
+	handler, ok := m.handlers[eventType]
+	if !ok {
+		handler = m.fallback
+	}
+	m.mutex.RUnlock()
+
+	if handler != nil {
+		handler(event)
+	}
+}
+
+// Serve dispatches every event received from events until it is closed, e.g.
+// the Events of a Stream returned by Client.Connect
+func (m *EventMux) Serve(events <-chan *Event) {
+	for event := range events {
+		m.Dispatch(event)
+	}
+}
@@ -0,0 +1,49 @@
+package sse
+
+import "testing"
+
+func TestEventMux_Dispatch(t *testing.T) {
+	var got []string
+	record := func(name string) EventHandlerFunc {
+		return func(event *Event) { got = append(got, name+":"+string(event.Data)) }
+	}
+
+	mux := NewEventMux()
+	mux.HandleEvent("update", record("update"))
+	mux.HandleEvent("message", record("message"))
+
+	tests := []struct {
+		testname string
+		event    *Event
+		expected []string
+	}{
+		{"RegisteredType", &Event{Type: "update", Data: []byte("1")}, []string{"update:1"}},
+		{"NoTypeIsMessage", &Event{Data: []byte("2")}, []string{"message:2"}},
+		{"UnknownTypeWithoutDefault", &Event{Type: "delete", Data: []byte("3")}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testname, func(t *testing.T) {
+			got = nil
+			mux.Dispatch(tt.event)
+			equals(t, tt.expected, got)
+		})
+	}
+
+	mux.HandleDefault(record("default"))
+	got = nil
+	mux.Dispatch(&Event{Type: "delete", Data: []byte("3")})
+	equals(t, []string{"default:3"}, got)
+}
+
+func TestEventMux_Serve(t *testing.T) {
+	events := make(chan *Event, 2)
+	events <- &Event{Type: "a"}
+	events <- &Event{Type: "b"}
+	close(events)
+
+	var types []string
+	mux := NewEventMux()
+	mux.HandleDefault(func(event *Event) { types = append(types, event.Type) })
+	mux.Serve(events)
+	equals(t, []string{"a", "b"}, types)
+}
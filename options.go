@@ -0,0 +1,244 @@
+package sse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ClientOption configures a Client created by NewClient. Every option sets
+// exported fields of the Client, which can also be set directly.
+type ClientOption func(*Client)
+
+// WithReconnect makes streams reconnect after delay, DefaultReconnectDelay if zero
+func WithReconnect(delay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.Reconnect = true
+		c.ReconnectDelay = delay
+	}
+}
+
+// WithRetryBudget limits the rate of reconnect attempts, see Client.RetryBudget
+func WithRetryBudget(budget *RetryBudget) ClientOption {
+	return func(c *Client) {
+		c.RetryBudget = budget
+	}
+}
+
+// WithEventQueue queues up to size events per stream, overflowing according to overflow
+func WithEventQueue(size int, overflow OverflowPolicy) ClientOption {
+	return func(c *Client) {
+		c.EventQueueSize = size
+		c.QueueOverflow = overflow
+	}
+}
+
+// WithIdleTimeout closes connections receiving nothing for timeout, see Client.IdleTimeout
+func WithIdleTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.IdleTimeout = timeout
+	}
+}
+
+// WithContentTypeCheck sets how strictly the Content-Type of responses is checked
+func WithContentTypeCheck(check ContentTypeCheck) ClientOption {
+	return func(c *Client) {
+		c.ContentTypeCheck = check
+	}
+}
+
+// WithHeader adds a default header sent with every request, see Client.Header
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		if c.Header == nil {
+			c.Header = make(http.Header)
+		}
+		c.Header.Add(key, value)
+	}
+}
+
+// WithBeforeConnect sets the hook called before every connection attempt
+func WithBeforeConnect(hook func(req *http.Request) error) ClientOption {
+	return func(c *Client) {
+		c.BeforeConnect = hook
+	}
+}
+
+// WithMetrics reports the measurements of every stream to m, see Client.Metrics
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.Metrics = m
+	}
+}
+
+// WithLogger sets the Logger told what happens inside streams, see Client.Logger
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// WithDeduplication drops events received already, ordering IDs with compare, see Client.Deduplicate
+func WithDeduplication(compare EventIDComparator) ClientOption {
+	return func(c *Client) {
+		c.Deduplicate = compare
+	}
+}
+
+// WithGapDetection reports gaps in the sequence numbers parsed from event IDs by parse, see Client.SequenceID
+func WithGapDetection(parse func(id string) (uint64, bool)) ClientOption {
+	return func(c *Client) {
+		c.SequenceID = parse
+	}
+}
+
+// WithEventIDStore persists the last event ID of every stream in store, see Client.EventIDStore
+func WithEventIDStore(store EventIDStore) ClientOption {
+	return func(c *Client) {
+		c.EventIDStore = store
+	}
+}
+
+// WithCommentHandler calls f with every comment the server sends, see Client.OnComment
+func WithCommentHandler(f func(req *http.Request, comment string)) ClientOption {
+	return func(c *Client) {
+		c.OnComment = f
+	}
+}
+
+// WithDefaultEventType sets the Type of events without an event field, see Client.DefaultEventType
+func WithDefaultEventType(eventType string) ClientOption {
+	return func(c *Client) {
+		c.DefaultEventType = eventType
+	}
+}
+
+// WithUTF8Policy sets how events that aren't valid UTF-8 are handled, see Client.InvalidUTF8
+func WithUTF8Policy(policy UTF8Policy) ClientOption {
+	return func(c *Client) {
+		c.InvalidUTF8 = policy
+	}
+}
+
+// WithFailover fails streams over to endpoints after threshold consecutive failed connection attempts, see Client.Failover
+func WithFailover(threshold int, endpoints ...string) ClientOption {
+	return func(c *Client) {
+		c.Failover = &Failover{Endpoints: endpoints, Threshold: threshold}
+	}
+}
+
+// WithTimeouts sets the timeouts of connection attempts and of the first event
+// of connections, see Client.ConnectTimeout and Client.FirstEventTimeout
+func WithTimeouts(connect, firstEvent time.Duration) ClientOption {
+	return func(c *Client) {
+		c.ConnectTimeout = connect
+		c.FirstEventTimeout = firstEvent
+	}
+}
+
+// WithMaxConnectionAge reconnects streams once their connection has been open for age, see Client.MaxConnectionAge
+func WithMaxConnectionAge(age time.Duration) ClientOption {
+	return func(c *Client) {
+		c.MaxConnectionAge = age
+	}
+}
+
+// WithEventPooling reuses the events delivered once released, see Client.PoolEvents
+func WithEventPooling() ClientOption {
+	return func(c *Client) {
+		c.PoolEvents = true
+	}
+}
+
+// WithHooks sets the lifecycle callbacks of streams, nil callbacks are left unset
+func WithHooks(onOpen func(resp *http.Response), onError, onClose func(req *http.Request, err error)) ClientOption {
+	return func(c *Client) {
+		if onOpen != nil {
+			c.OnOpen = onOpen
+		}
+		if onError != nil {
+			c.OnError = onError
+		}
+		if onClose != nil {
+			c.OnClose = onClose
+		}
+	}
+}
+
+// StreamOption configures a single stream started by Client.Stream or
+// Client.Connect, overriding the configuration of the Client for it
+type StreamOption func(req *http.Request) *http.Request
+
+// StreamHeader sets a header of the stream's requests
+func StreamHeader(key, value string) StreamOption {
+	return func(req *http.Request) *http.Request {
+		req.Header.Set(key, value)
+		return req
+	}
+}
+
+// StreamMetadata attaches md to every event of the stream, see WithMetadata
+func StreamMetadata(md Metadata) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithMetadata(ctx, md) })
+}
+
+// StreamTee copies the raw bytes of the stream to w, see WithTee
+func StreamTee(w io.Writer) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithTee(ctx, w) })
+}
+
+// StreamStatusPolicy decides which non-200 responses the stream reconnects, see WithStatusPolicy
+func StreamStatusPolicy(policy StatusPolicy) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithStatusPolicy(ctx, policy) })
+}
+
+// StreamBackpressure sets how the events of the stream are queued, see WithBackpressure
+func StreamBackpressure(bp Backpressure) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithBackpressure(ctx, bp) })
+}
+
+// StreamMetricsLabel labels the measurements of the stream, see WithMetricsLabel
+func StreamMetricsLabel(label string) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithMetricsLabel(ctx, label) })
+}
+
+// StreamEventIDKey identifies the stream by key in the Client's EventIDStore, see WithEventIDKey
+func StreamEventIDKey(key string) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithEventIDKey(ctx, key) })
+}
+
+// StreamEventFilter only delivers the events of the stream filter returns true for, see WithEventFilter
+func StreamEventFilter(filter func(*Event) bool) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithEventFilter(ctx, filter) })
+}
+
+// StreamEventTypes only reads the events of the stream of the given types, see WithEventTypes
+func StreamEventTypes(types ...string) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithEventTypes(ctx, types...) })
+}
+
+// StreamEventMiddleware passes the events of the stream through middlewares before delivering them, see WithEventMiddleware
+func StreamEventMiddleware(middlewares ...EventMiddleware) StreamOption {
+	return streamContext(func(ctx context.Context) context.Context { return WithEventMiddleware(ctx, middlewares...) })
+}
+
+// streamContext is a StreamOption changing the context of the stream's request
+func streamContext(with func(context.Context) context.Context) StreamOption {
+	return func(req *http.Request) *http.Request {
+		return req.WithContext(with(req.Context()))
+	}
+}
+
+// applyStreamOptions returns req configured by opts. The request is copied so
+// the caller's isn't changed.
+func applyStreamOptions(req *http.Request, opts []StreamOption) *http.Request {
+	if len(opts) == 0 {
+		return req
+	}
+	req = cloneRequest(req)
+	for _, opt := range opts {
+		req = opt(req)
+	}
+	return req
+}
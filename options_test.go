@@ -0,0 +1,52 @@
+package sse
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewClient_options(t *testing.T) {
+	budget := NewRetryBudget(1, time.Second)
+	c := NewClient(http.DefaultClient,
+		WithReconnect(time.Second),
+		WithRetryBudget(budget),
+		WithEventQueue(8, OverflowDropOldest),
+		WithIdleTimeout(time.Minute),
+		WithContentTypeCheck(ContentTypeStrict),
+		WithHooks(nil, func(*http.Request, error) {}, nil),
+	)
+
+	assert(t, c.Reconnect, "reconnect should be set")
+	equals(t, time.Second, c.ReconnectDelay)
+	equals(t, budget, c.RetryBudget)
+	equals(t, 8, c.EventQueueSize)
+	equals(t, OverflowDropOldest, c.QueueOverflow)
+	equals(t, time.Minute, c.IdleTimeout)
+	equals(t, ContentTypeStrict, c.ContentTypeCheck)
+	assert(t, c.OnOpen == nil && c.OnError != nil && c.OnClose == nil, "only the given hooks should be set")
+}
+
+func TestClient_Stream_options(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: " + r.Header.Get("Authorization") + "\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	var tee bytes.Buffer
+	eventch, _ := NewClient(server.Client()).Stream(req,
+		StreamHeader("Authorization", "Bearer token"),
+		StreamMetadata(Metadata{"tenant": "acme"}),
+		StreamTee(&tee),
+	)
+
+	event := <-eventch
+	equals(t, "Bearer token", string(event.Data))
+	equals(t, Metadata{"tenant": "acme"}, event.Metadata)
+	equals(t, "data: Bearer token\n\n", tee.String())
+	equals(t, "", req.Header.Get("Authorization"))
+}
@@ -0,0 +1,148 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// PayloadDecoder decodes the data of an event, e.g. decompressing it
+type PayloadDecoder func(data []byte) ([]byte, error)
+
+// PayloadDecoding configures decoding of compressed or encoded event data,
+// so consumers receive the decoded data transparently
+type PayloadDecoding struct {
+	// Field is a non-standard field naming the encoding of an event's data
+	// (e.g. "encoding" for "encoding: gzip+base64")
+	Field string
+	// Encodings maps the encoding names sent in Field to their decoder,
+	// the defaults of DefaultPayloadEncodings() limited to Client.MaxEventSize
+	// if nil
+	Encodings map[string]PayloadDecoder
+	// Types maps event types to the decoder of their data, for servers that
+	// always encode some event types without naming the encoding
+	Types map[string]PayloadDecoder
+}
+
+// DefaultPayloadEncodings returns the decoders for "base64", "gzip" and "gzip+base64"
+func DefaultPayloadEncodings() map[string]PayloadDecoder {
+	return payloadEncodings(bufio.MaxScanTokenSize)
+}
+
+// payloadEncodings returns the default decoders, decompressing at most limit bytes
+func payloadEncodings(limit int) map[string]PayloadDecoder {
+	return map[string]PayloadDecoder{
+		"base64":      Base64Decoder,
+		"gzip":        GzipDecoderLimit(limit),
+		"gzip+base64": GzipBase64DecoderLimit(limit),
+	}
+}
+
+// Base64Decoder decodes standard base64 data
+func Base64Decoder(data []byte) ([]byte, error) {
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(decoded, data)
+	return decoded[:n], err
+}
+
+// GzipDecoder decompresses gzip data of at most 64KB, the default
+// Client.MaxEventSize. Larger data fails with ErrEventTooLarge.
+func GzipDecoder(data []byte) ([]byte, error) {
+	return gunzip(data, bufio.MaxScanTokenSize)
+}
+
+// GzipDecoderLimit returns a decoder decompressing gzip data of at most
+// limit bytes. Larger data fails with ErrEventTooLarge.
+func GzipDecoderLimit(limit int) PayloadDecoder {
+	return func(data []byte) ([]byte, error) {
+		return gunzip(data, limit)
+	}
+}
+
+// GzipBase64Decoder decompresses base64 encoded gzip data of at most 64KB,
+// the default Client.MaxEventSize. Larger data fails with ErrEventTooLarge.
+func GzipBase64Decoder(data []byte) ([]byte, error) {
+	return GzipBase64DecoderLimit(bufio.MaxScanTokenSize)(data)
+}
+
+// GzipBase64DecoderLimit returns a decoder decompressing base64 encoded gzip
+// data of at most limit bytes. Larger data fails with ErrEventTooLarge.
+func GzipBase64DecoderLimit(limit int) PayloadDecoder {
+	return func(data []byte) ([]byte, error) {
+		compressed, err := Base64Decoder(data)
+		if err != nil {
+			return nil, err
+		}
+		return gunzip(compressed, limit)
+	}
+}
+
+// gunzip decompresses data, reading one byte past limit to tell whether
+// it decompresses to more
+func gunzip(data []byte, limit int) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > limit {
+		return nil, ErrEventTooLarge
+	}
+	return decompressed, nil
+}
+
+// PayloadError is passed through the error channel when the data of an event
+// couldn't be decoded. The event isn't delivered.
+type PayloadError struct {
+	Event *Event
+	Err   error
+}
+
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("decoding data of %s event: %s", e.Event.Type, e.Err.Error())
+}
+
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+// decodePayload decodes the data of event in place if it is encoded. The
+// default decoders decompress at most maxSize bytes, 64KB if zero.
+func (d *PayloadDecoding) decodePayload(event *Event, eventBytes []byte, maxSize int) error {
+	if d == nil {
+		return nil
+	}
+
+	decoder := d.Types[event.Type]
+	if d.Field != "" {
+		if encoding, ok := findField(eventBytes, []byte(d.Field)); ok {
+			encodings := d.Encodings
+			if encodings == nil {
+				if maxSize <= 0 {
+					maxSize = bufio.MaxScanTokenSize
+				}
+				encodings = payloadEncodings(maxSize)
+			}
+			if decoder, ok = encodings[string(encoding)]; !ok {
+				return &PayloadError{Event: event, Err: fmt.Errorf("unknown encoding %q", encoding)}
+			}
+		}
+	}
+	if decoder == nil {
+		return nil
+	}
+
+	data, err := decoder(event.Data)
+	if err != nil {
+		return &PayloadError{Event: event, Err: err}
+	}
+	event.Data = data
+	return nil
+}
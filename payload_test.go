@@ -0,0 +1,73 @@
+package sse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func gzipBase64(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(data))
+	ok(t, err)
+	ok(t, writer.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestPayloadDecoding_decodePayload(t *testing.T) {
+	compressed := gzipBase64(t, `{"price":42}`)
+
+	tests := []struct {
+		testname  string
+		decoding  *PayloadDecoding
+		input     string
+		expected  string
+		shouldErr bool
+	}{
+		{"nothing configured", nil, "data: " + compressed, compressed, false},
+		{"encoding field", &PayloadDecoding{Field: "encoding"}, "encoding: gzip+base64\ndata: " + compressed, `{"price":42}`, false},
+		{"no encoding field", &PayloadDecoding{Field: "encoding"}, "data: plain", "plain", false},
+		{"unknown encoding", &PayloadDecoding{Field: "encoding"}, "encoding: zstd\ndata: abc", "", true},
+		{"by event type", &PayloadDecoding{Types: map[string]PayloadDecoder{"price": GzipBase64Decoder}}, "event: price\ndata: " + compressed, `{"price":42}`, false},
+		{"corrupt data", &PayloadDecoding{Types: map[string]PayloadDecoder{"price": GzipBase64Decoder}}, "event: price\ndata: !!!", "", true},
+	}
+
+	for _, test := range tests {
+		event, err := readEvent([]byte(test.input))
+		ok(t, err)
+		err = test.decoding.decodePayload(event, []byte(test.input), 0)
+		if test.shouldErr {
+			_, isPayloadErr := err.(*PayloadError)
+			assert(t, isPayloadErr, "%s: expected a *PayloadError, got %v", test.testname, err)
+			continue
+		}
+		ok(t, err)
+		equals(t, test.expected, string(event.Data))
+	}
+}
+
+func TestPayloadDecoding_decodePayloadLimit(t *testing.T) {
+	bomb := gzipBase64(t, strings.Repeat("a", 1<<20))
+	decoding := &PayloadDecoding{Field: "encoding"}
+	input := "encoding: gzip+base64\ndata: " + bomb
+
+	event, err := readEvent([]byte(input))
+	ok(t, err)
+	err = decoding.decodePayload(event, []byte(input), 0)
+	assert(t, errors.Is(err, ErrEventTooLarge), "expected ErrEventTooLarge, got %v", err)
+
+	event, err = readEvent([]byte(input))
+	ok(t, err)
+	ok(t, decoding.decodePayload(event, []byte(input), 2<<20))
+	equals(t, 1<<20, len(event.Data))
+
+	_, err = GzipBase64Decoder([]byte(bomb))
+	assert(t, errors.Is(err, ErrEventTooLarge), "expected ErrEventTooLarge, got %v", err)
+	data, err := GzipBase64DecoderLimit(1 << 20)([]byte(bomb))
+	ok(t, err)
+	equals(t, 1<<20, len(data))
+}
@@ -0,0 +1,182 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusPolicy is a table deciding, by response status code, whether a stream
+// reconnects after a non-200 response. Keys are either exact status codes
+// ("429") or status classes ("5xx"); exact codes take precedence. Status codes
+// matching neither are reconnected.
+type StatusPolicy map[string]bool
+
+// DefaultStatusPolicy returns the policy used when Client.StatusPolicy is nil.
+// Following the EventSource spec, 204 No Content is the server asking the
+// client to stop reconnecting. Client errors are permanent, retrying the same
+// request won't help, except for timeouts and rate limiting. Server errors
+// are transient.
+func DefaultStatusPolicy() StatusPolicy {
+	return StatusPolicy{
+		"204": false,
+		// unauthorized, forbidden, gone or not found won't change by retrying
+		"401": false,
+		"403": false,
+		"404": false,
+		"410": false,
+		"4xx": false,
+		"408": true,
+		"429": true,
+		"5xx": true,
+	}
+}
+
+type statusPolicyKey struct{}
+
+// WithStatusPolicy returns a copy of ctx carrying policy. Streams started from
+// a request with this context use policy instead of Client.StatusPolicy.
+func WithStatusPolicy(ctx context.Context, policy StatusPolicy) context.Context {
+	return context.WithValue(ctx, statusPolicyKey{}, policy)
+}
+
+// statusPolicyFromContext returns the policy stored in ctx by WithStatusPolicy, or nil
+func statusPolicyFromContext(ctx context.Context) StatusPolicy {
+	policy, _ := ctx.Value(statusPolicyKey{}).(StatusPolicy)
+	return policy
+}
+
+// shouldReconnect looks up statusCode in the policy
+func (p StatusPolicy) shouldReconnect(statusCode int) bool {
+	if reconnect, ok := p[strconv.Itoa(statusCode)]; ok {
+		return reconnect
+	}
+	if reconnect, ok := p[fmt.Sprintf("%dxx", statusCode/100)]; ok {
+		return reconnect
+	}
+	return true
+}
+
+// HTTPErrorBodyLimit is the number of bytes of the body of a non-200
+// response kept in an HTTPError
+const HTTPErrorBodyLimit = 4 << 10
+
+// HTTPError is passed through the error channel when the stream responds with
+// a non-200 status code. Use errors.As to tell e.g. a 401 from a 503.
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	// Body is the start of the response body, up to HTTPErrorBodyLimit bytes
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("non-200 status code from stream: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// newHTTPError reads the start of the body of a non-200 response
+func newHTTPError(resp *http.Response) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, HTTPErrorBodyLimit))
+	return &HTTPError{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+}
+
+// shouldReconnect checks the status policy of the stream for errors caused by the response status
+func (c *Client) shouldReconnect(ctx context.Context, err error) bool {
+	if errors.Is(err, ErrEventTooLarge) || errors.Is(err, ErrStreamingUnsupported) {
+		return false
+	}
+	statusCode, ok := StatusCode(err)
+	if !ok {
+		return true
+	}
+
+	policy := statusPolicyFromContext(ctx)
+	if policy == nil {
+		policy = c.StatusPolicy
+	}
+	if policy == nil {
+		policy = DefaultStatusPolicy()
+	}
+	return policy.shouldReconnect(statusCode)
+}
+
+// StatusCode returns the response status code of an error caused by a non-200 response
+func StatusCode(err error) (int, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode, true
+	}
+	return 0, false
+}
+
+// retryAfter returns the delay asked for in the Retry-After header of a 429
+// or 503 response, either in seconds or as an HTTP date
+func retryAfter(err error) (time.Duration, bool) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Header == nil {
+		return 0, false
+	}
+	if httpErr.StatusCode != http.StatusTooManyRequests && httpErr.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(httpErr.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := time.Until(date)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// ReconnectAction is what a stream does after a failure
+type ReconnectAction int
+
+const (
+	// ActionRetry reconnects after the usual reconnect delay
+	ActionRetry ReconnectAction = iota
+	// ActionRetryAfter reconnects after Decision.Delay
+	ActionRetryAfter
+	// ActionFatal stops the stream
+	ActionFatal
+)
+
+// Decision is returned by an ErrorClassifier
+type Decision struct {
+	Action ReconnectAction
+	// Delay is the delay before reconnecting for ActionRetryAfter
+	Delay time.Duration
+}
+
+// ErrorClassifier decides what a reconnecting stream does after a failure.
+// StatusCode can be used to get the status code of non-200 responses.
+type ErrorClassifier func(err error) Decision
+
+// classify decides what to do after err, using the ErrorClassifier if set
+// and the status policy of the stream otherwise
+func (c *Client) classify(ctx context.Context, err error) Decision {
+	if c.ErrorClassifier != nil {
+		return c.ErrorClassifier(err)
+	}
+	if c.shouldReconnect(ctx, err) {
+		return Decision{Action: ActionRetry}
+	}
+	return Decision{Action: ActionFatal}
+}
@@ -0,0 +1,177 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStatusPolicy_shouldReconnect(t *testing.T) {
+	tests := []struct {
+		testname   string
+		policy     StatusPolicy
+		statusCode int
+		expected   bool
+	}{
+		{"default 500", DefaultStatusPolicy(), 500, true},
+		{"default 503", DefaultStatusPolicy(), 503, true},
+		{"default 429", DefaultStatusPolicy(), 429, true},
+		{"default 401", DefaultStatusPolicy(), 401, false},
+		{"default 403", DefaultStatusPolicy(), 403, false},
+		{"default 404", DefaultStatusPolicy(), 404, false},
+		{"default 408", DefaultStatusPolicy(), 408, true},
+		{"default 418", DefaultStatusPolicy(), 418, false},
+		{"default 204", DefaultStatusPolicy(), 204, false},
+		{"default 302", DefaultStatusPolicy(), 302, true},
+		{"override code", StatusPolicy{"4xx": false, "408": true}, 408, true},
+		{"override class", StatusPolicy{"5xx": false}, 502, false},
+	}
+
+	for _, test := range tests {
+		assert(t, test.policy.shouldReconnect(test.statusCode) == test.expected, "%s: expected %v", test.testname, test.expected)
+	}
+}
+
+func TestClient_shouldReconnect(t *testing.T) {
+	c := &Client{}
+	assert(t, c.shouldReconnect(context.Background(), errors.New("connection reset")), "should reconnect after network errors")
+	assert(t, !c.shouldReconnect(context.Background(), &HTTPError{StatusCode: 401}), "shouldn't reconnect after a 401 by default")
+
+	c.StatusPolicy = StatusPolicy{"401": true}
+	assert(t, c.shouldReconnect(context.Background(), &HTTPError{StatusCode: 401}), "should reconnect after a 401 with an override")
+
+	ctx := WithStatusPolicy(context.Background(), StatusPolicy{"401": false})
+	assert(t, !c.shouldReconnect(ctx, &HTTPError{StatusCode: 401}), "shouldn't reconnect after a 401 with a per-stream override")
+}
+
+func TestClient_classify(t *testing.T) {
+	c := &Client{}
+	equals(t, Decision{Action: ActionRetry}, c.classify(context.Background(), &HTTPError{StatusCode: 503}))
+	equals(t, Decision{Action: ActionFatal}, c.classify(context.Background(), &HTTPError{StatusCode: 403}))
+
+	c.ErrorClassifier = func(err error) Decision {
+		if code, ok := StatusCode(err); ok && code == 599 {
+			return Decision{Action: ActionRetryAfter, Delay: time.Minute}
+		}
+		return Decision{Action: ActionFatal}
+	}
+	equals(t, Decision{Action: ActionRetryAfter, Delay: time.Minute}, c.classify(context.Background(), &HTTPError{StatusCode: 599}))
+	equals(t, Decision{Action: ActionFatal}, c.classify(context.Background(), errors.New("no such host")))
+}
+
+func TestClient_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"slow down"}` + strings.Repeat(" ", HTTPErrorBodyLimit)))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	_, errch := NewClient(server.Client()).Stream(req)
+
+	err = <-errch
+	var httpErr *HTTPError
+	assert(t, errors.As(err, &httpErr), "expected an *HTTPError, got %v", err)
+	equals(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	equals(t, "0", httpErr.Header.Get("X-RateLimit-Remaining"))
+	equals(t, HTTPErrorBodyLimit, len(httpErr.Body))
+	assert(t, strings.HasPrefix(string(httpErr.Body), `{"error":"slow down"}`), "unexpected body %q", httpErr.Body)
+	equals(t, "non-200 status code from stream: 429 Too Many Requests", err.Error())
+
+	code, isHTTP := StatusCode(fmt.Errorf("wrapped: %w", err))
+	equals(t, http.StatusTooManyRequests, code)
+	assert(t, isHTTP, "StatusCode should unwrap errors")
+}
+
+func Test_retryAfter(t *testing.T) {
+	withRetryAfter := func(statusCode int, value string) error {
+		return &HTTPError{StatusCode: statusCode, Header: http.Header{"Retry-After": {value}}}
+	}
+
+	tests := []struct {
+		testname string
+		err      error
+		expected time.Duration
+		found    bool
+	}{
+		{"Seconds429", withRetryAfter(429, "120"), 2 * time.Minute, true},
+		{"Seconds503", withRetryAfter(503, " 5 "), 5 * time.Second, true},
+		{"PastDate", withRetryAfter(503, "Wed, 21 Oct 2015 07:28:00 GMT"), 0, true},
+		{"OtherStatus", withRetryAfter(500, "5"), 0, false},
+		{"Negative", withRetryAfter(429, "-5"), 0, false},
+		{"Invalid", withRetryAfter(429, "soon"), 0, false},
+		{"NoHeader", &HTTPError{StatusCode: 429}, 0, false},
+		{"NotHTTP", errors.New("connection reset"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testname, func(t *testing.T) {
+			delay, found := retryAfter(tt.err)
+			equals(t, tt.expected, delay)
+			equals(t, tt.found, found)
+		})
+	}
+
+	delay, found := retryAfter(withRetryAfter(503, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)))
+	assert(t, found && delay > 59*time.Minute && delay <= time.Hour, "unexpected delay %v for a date", delay)
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.Reconnect = true
+	// only Retry-After lets the stream reconnect within the test
+	c.ReconnectDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	equals(t, http.StatusServiceUnavailable, (<-errch).(*HTTPError).StatusCode)
+	select {
+	case event := <-eventch:
+		equals(t, "a", string(event.Data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't reconnect after Retry-After")
+	}
+}
+
+func TestClient_NoContentStops(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	var errs []error
+	for result := range collect(NewClient(server.Client(), WithReconnect(time.Millisecond)).Stream(req)) {
+		errs = append(errs, result.Err)
+	}
+
+	equals(t, 1, len(errs))
+	code, _ := StatusCode(errs[0])
+	equals(t, http.StatusNoContent, code)
+	equals(t, int32(1), atomic.LoadInt32(&connections))
+}
@@ -0,0 +1,56 @@
+package sse
+
+import "sync"
+
+// eventPool holds the events of Clients with PoolEvents set, released by
+// their consumers
+var eventPool = sync.Pool{New: func() interface{} { return &Event{} }}
+
+// getEvent returns an event of the pool, to be given back with Release
+func getEvent() *Event {
+	event := eventPool.Get().(*Event)
+	event.pooled = true
+	return event
+}
+
+// Release gives an event received from a Client with PoolEvents set back to
+// the Client, to be reused for a later event. Neither the event nor its Data
+// may be used once it is released, use Clone to keep a copy. Releasing an
+// event more than once, or an event that wasn't pooled, does nothing.
+func (e *Event) Release() {
+	if e == nil || !e.pooled {
+		return
+	}
+	// Type and LastEventID are kept, so the next event having the same ones
+	// doesn't allocate them again
+	*e = Event{LastEventID: e.LastEventID, Type: e.Type, Data: e.Data[:0]}
+	eventPool.Put(e)
+}
+
+// Clone returns a copy of the event owning its Data, which is never pooled
+// and stays valid after the event is released
+func (e *Event) Clone() *Event {
+	clone := *e
+	clone.pooled = false
+	if e.Data != nil {
+		clone.Data = append([]byte(nil), e.Data...)
+	}
+	return &clone
+}
+
+// parseEvent reads the event of a block, from the pool if PoolEvents is set.
+// It returns false for the blocks to ignore: those readEventInto rejects and,
+// when deduplicating, the events received already.
+func (c *Client) parseEvent(eventBytes []byte, lastEventID string) (*Event, bool) {
+	var event *Event
+	if c.PoolEvents {
+		event = getEvent()
+	} else {
+		event = &Event{}
+	}
+	if err := readEventInto(event, eventBytes); err != nil || c.isDuplicate(event, lastEventID) {
+		event.Release()
+		return nil, false
+	}
+	return event, true
+}
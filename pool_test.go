@@ -0,0 +1,151 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEvent_Release(t *testing.T) {
+	event := getEvent()
+	ok(t, readEventInto(event, []byte("event: update\nid: 1\ndata: hello")))
+	clone := event.Clone()
+
+	event.Release()
+	assert(t, !event.pooled, "expected released event not to be pooled")
+	equals(t, 0, len(event.Data))
+	// released twice does nothing
+	event.Release()
+
+	equals(t, &Event{Type: "update", LastEventID: "1", Data: []byte("hello")}, clone)
+	clone.Release()
+	equals(t, "hello", string(clone.Data))
+}
+
+func TestReadEventInto_allocations(t *testing.T) {
+	block := []byte("event: update\nid: 42\ndata: {\"n\":1}\ndata: more\n: comment")
+	// the pool itself drops events at random under the race detector, so
+	// the event is reused as a released pooled event would be
+	event := &Event{}
+	ok(t, readEventInto(event, block))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := readEventInto(event, block); err != nil {
+			t.Fatal(err)
+		}
+	})
+	equals(t, 0.0, allocs)
+}
+
+func TestClient_PoolEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\nid: 1\n\ndata: b\n\ndata: c\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithEventPooling())
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	var kept []*Event
+	var data []string
+	for r := range collect(c.Stream(req)) {
+		if r.Event == nil {
+			continue
+		}
+		data = append(data, string(r.Event.Data))
+		assert(t, r.Event.pooled, "expected a pooled event")
+		kept = append(kept, r.Event.Clone())
+		r.Event.Release()
+	}
+	equals(t, []string{"a", "b", "c"}, data)
+	for i, event := range kept {
+		equals(t, data[i], string(event.Data))
+	}
+}
+
+func BenchmarkClient_PoolEvents(b *testing.B) {
+	for _, pooled := range []bool{false, true} {
+		name := "allocated"
+		if pooled {
+			name = "pooled"
+		}
+		b.Run(name, func(b *testing.B) {
+			stream := make([]byte, 0, b.N*32)
+			for i := 0; i < b.N; i++ {
+				stream = append(stream, "event: tick\ndata: 0123456789\n\n"...)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(stream)
+			}))
+			defer server.Close()
+
+			c := NewClient(server.Client())
+			c.PoolEvents = pooled
+			c.ReconnectDelay = time.Hour
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			eventch, errch := c.Stream(req)
+			for r := range collect(eventch, errch) {
+				if r.Event != nil {
+					r.Event.Release()
+				}
+			}
+		})
+	}
+}
+
+func TestClient_PoolEventsReleasedRightAway(t *testing.T) {
+	const events = 50
+	acks := make(chan string, events)
+	companion := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		acks <- r.URL.Query().Get("id") + " " + string(body)
+	}))
+	defer companion.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < events; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: %d\n\n", i, i)
+		}
+		// the companion only runs while the connection is open
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithEventPooling())
+	c.Companion = &Companion{
+		URLTemplate: companion.URL + "?id={id}",
+		AckEvents:   true,
+		Payload:     func(event *Event) (string, []byte) { return "text/plain", event.Data },
+	}
+	// every delivery is slow, so the hook runs for every event too
+	c.DeliveryDeadline = time.Nanosecond
+	c.OnSlowDelivery = func(s SlowDelivery) { _ = s.Event.LastEventID + s.Event.Type }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	// the consumer releases events as soon as it has them, while the
+	// companion and the slow delivery hook still use what they need of them
+	eventch, errch := c.Stream(req)
+	for i := 0; i < events; i++ {
+		(<-eventch).Release()
+	}
+	for i := 0; i < events; i++ {
+		equals(t, fmt.Sprintf("%d %d", i, i), <-acks)
+	}
+	cancel()
+	for range collect(eventch, errch) {
+	}
+}
@@ -0,0 +1,121 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrNoHealthyEndpoint is returned by HealthProber.Probe when every candidate failed its probe
+var ErrNoHealthyEndpoint = errors.New("no healthy endpoint")
+
+// DefaultProbeTimeout is the timeout of a probe when HealthProber.Timeout isn't set
+const DefaultProbeTimeout = 2 * time.Second
+
+// HealthProber checks endpoints with a short request before connecting to them,
+// so reconnect attempts aren't wasted on endpoints that are still down
+type HealthProber struct {
+	// Method is the method of probe requests, http.MethodHead if empty
+	Method string
+	// Timeout is the timeout of a probe, DefaultProbeTimeout if zero
+	Timeout time.Duration
+	// Healthy decides if a probe response is healthy. By default any status
+	// code below 500 is healthy.
+	Healthy func(resp *http.Response) bool
+	// Failover lists other URLs serving the same stream. They are the first
+	// of the stream's endpoints after its URL, followed by Failover.Endpoints
+	// of the Client, see Failover. Before reconnecting, the stream's current
+	// endpoint and then the ones after it are probed in order, and the stream
+	// reconnects to the first healthy one.
+	Failover []string
+}
+
+// Probe probes the candidates in order and returns the first healthy one
+func (p *HealthProber) Probe(ctx context.Context, httpClient *http.Client, candidates ...string) (string, error) {
+	var lastErr error
+	for _, candidate := range candidates {
+		if lastErr = p.probe(ctx, httpClient, candidate); lastErr == nil {
+			return candidate, nil
+		}
+	}
+	if lastErr == nil {
+		return "", ErrNoHealthyEndpoint
+	}
+	return "", fmt.Errorf("%v: %v", ErrNoHealthyEndpoint, lastErr)
+}
+
+// probe sends a single probe request
+func (p *HealthProber) probe(ctx context.Context, httpClient *http.Client, url string) error {
+	method := p.Method
+	if method == "" {
+		method = http.MethodHead
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	// the body of a healthy SSE endpoint may never end, so it isn't drained
+	resp.Body.Close()
+
+	healthy := resp.StatusCode < 500
+	if p.Healthy != nil {
+		healthy = p.Healthy(resp)
+	}
+	if !healthy {
+		return fmt.Errorf("probe of %s responded with status code %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// waitForHealthy probes the endpoint of req and then the other endpoints of
+// the stream in rotation order until one is healthy, waiting the reconnect
+// delay between rounds of probes. It returns the request to reconnect with,
+// or false if the stream was stopped.
+func (c *Client) waitForHealthy(req *http.Request, stopch <-chan struct{}, state *streamState) (*http.Request, bool) {
+	current := req.URL.String()
+	candidates := append([]string{current}, rotation(current, state.endpoints)...)
+
+	delay := c.ReconnectDelay
+	if delay <= 0 {
+		delay = DefaultReconnectDelay
+	}
+
+	for {
+		healthy, err := c.HealthProber.Probe(req.Context(), c.HTTPClient, candidates...)
+		if err == nil {
+			if healthy == current {
+				return req, true
+			}
+			u, err := url.Parse(healthy)
+			if err == nil {
+				return applyControl(req, &Control{Directive: ControlRedirect, URL: u}), true
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-stopch:
+			timer.Stop()
+			return nil, false
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, false
+		}
+	}
+}
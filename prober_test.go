@@ -0,0 +1,92 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthProber_Probe(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	methods := make(chan string, 1)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods <- r.Method
+	}))
+	defer up.Close()
+
+	prober := &HealthProber{}
+
+	healthy, err := prober.Probe(context.Background(), http.DefaultClient, down.URL, up.URL)
+	ok(t, err)
+	equals(t, up.URL, healthy)
+	equals(t, http.MethodHead, <-methods)
+
+	_, err = prober.Probe(context.Background(), http.DefaultClient, down.URL)
+	assert(t, err != nil, "should've errored but didn't")
+
+	_, err = prober.Probe(context.Background(), http.DefaultClient)
+	equals(t, ErrNoHealthyEndpoint, err)
+}
+
+func TestClient_HealthProberFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte("data: failover\n\n"))
+		}
+	}))
+	defer up.Close()
+
+	c := NewClient(http.DefaultClient)
+	c.Reconnect = true
+	c.ReconnectDelay = time.Millisecond
+	c.HealthProber = &HealthProber{Failover: []string{up.URL}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, down.URL, nil)
+	ok(t, err)
+
+	eventch, errch := c.Stream(req.WithContext(ctx))
+	statusCode, _ := StatusCode(<-errch)
+	equals(t, http.StatusServiceUnavailable, statusCode)
+	select {
+	case event := <-eventch:
+		equals(t, "failover", string(event.Data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't fail over to the healthy endpoint")
+	}
+}
+
+func TestClient_HealthProberWaitsReconnectDelay(t *testing.T) {
+	var gets int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// healthy, but the stream ends right away
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&gets, 1)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client())
+	c.Reconnect = true
+	c.ReconnectDelay = 100 * time.Millisecond
+	c.HealthProber = &HealthProber{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	ok(t, err)
+	for range collect(c.Stream(req)) {
+	}
+	assert(t, atomic.LoadInt32(&gets) <= 5, "expected the reconnect delay between connections, got %d of them", gets)
+}
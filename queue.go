@@ -0,0 +1,176 @@
+package sse
+
+import "time"
+
+// deliveryQueue buffers results between a stream's connection and its consumer,
+// delivering priority events ahead of everything already queued
+type deliveryQueue struct {
+	in   chan Result
+	done chan struct{}
+	// abort is closed once nothing may be delivered anymore
+	abort chan struct{}
+}
+
+// queuedResult is a result along with the time it was queued
+type queuedResult struct {
+	Result
+	queuedAt time.Time
+}
+
+// newDeliveryQueue starts a queue holding up to bp.QueueSize non-priority
+// results, overflowing according to bp.Overflow. The queue gives up on
+// delivering once stop or ctxDone is closed, dropping whatever is still queued.
+// dropped, if not nil, is called with every event dropped by the overflow policy.
+func newDeliveryQueue(bp Backpressure, isPriority func(*Event) bool, slow *slowConsumerDetector, dropped func(*Event), eventch chan<- *Event, errch chan<- error, stop, ctxDone <-chan struct{}) *deliveryQueue {
+	q := &deliveryQueue{
+		in:    make(chan Result),
+		done:  make(chan struct{}),
+		abort: make(chan struct{}),
+	}
+	go q.run(bp, isPriority, slow, dropped, eventch, errch, stop, ctxDone)
+	return q
+}
+
+// push adds a result to the queue, blocking while the queue is full.
+// The result is dropped if the queue has given up on delivering.
+func (q *deliveryQueue) push(r Result) {
+	select {
+	case q.in <- r:
+	case <-q.abort:
+	}
+}
+
+// close stops accepting results and waits for the queued ones to be
+// delivered, or for the queue to give up on them
+func (q *deliveryQueue) close() {
+	close(q.in)
+	<-q.done
+}
+
+func (q *deliveryQueue) run(bp Backpressure, isPriority func(*Event) bool, slow *slowConsumerDetector, dropped func(*Event), eventch chan<- *Event, errch chan<- error, stop, ctxDone <-chan struct{}) {
+	defer close(q.done)
+
+	var priority, normal []queuedResult
+	// pending is a result that didn't fit in the queue, nothing else is read
+	// until it does
+	var pending *queuedResult
+	in := q.in
+
+	for in != nil || len(priority) > 0 || len(normal) > 0 {
+		if pending != nil && len(normal) < bp.QueueSize {
+			normal = append(normal, *pending)
+			pending = nil
+		}
+
+		// nil channels are never selected, so only the channels that
+		// have something to do are enabled below
+		var next queuedResult
+		var eventOut chan<- *Event
+		var errOut chan<- error
+		var delivered Event
+		switch {
+		case len(priority) > 0:
+			next = priority[0]
+		case len(normal) > 0:
+			next = normal[0]
+		}
+		if next.Err != nil {
+			errOut = errch
+		} else if next.Event != nil {
+			eventOut = eventch
+			delivered = slow.snapshot(next.Event)
+		}
+
+		input := in
+		if pending != nil {
+			input = nil
+		}
+
+		select {
+		case r, ok := <-input:
+			switch {
+			case !ok:
+				in = nil
+			case r.Event != nil && isPriority(r.Event):
+				priority = append(priority, queuedResult{r, time.Now()})
+			default:
+				queued := queuedResult{r, time.Now()}
+				var drop *Event
+				var ok bool
+				if normal, drop, ok = enqueue(normal, queued, bp); !ok {
+					pending = &queued
+				}
+				if drop != nil && dropped != nil {
+					dropped(drop)
+				}
+			}
+		case eventOut <- next.Event:
+			slow.observe(delivered, time.Since(next.queuedAt))
+			priority, normal = popResult(priority, normal)
+		case errOut <- next.Err:
+			priority, normal = popResult(priority, normal)
+		case <-stop:
+			close(q.abort)
+			return
+		case <-ctxDone:
+			close(q.abort)
+			return
+		}
+	}
+}
+
+// enqueue adds a non-priority result to the queue, applying the overflow
+// policy. It returns the event dropped to apply it, if any, and false if the
+// result has to wait for the queue to have room.
+func enqueue(normal []queuedResult, r queuedResult, bp Backpressure) ([]queuedResult, *Event, bool) {
+	if r.Event == nil {
+		// errors are never dropped
+		return append(normal, r), nil, true
+	}
+
+	if bp.Overflow == OverflowCoalesce && r.Event.LastEventID != "" {
+		for i := range normal {
+			if normal[i].Event != nil && normal[i].Event.LastEventID == r.Event.LastEventID {
+				// the event keeps its place in the queue, but not its age
+				normal[i] = r
+				return normal, nil, true
+			}
+		}
+	}
+	if len(normal) < bp.QueueSize {
+		return append(normal, r), nil, true
+	}
+
+	switch bp.Overflow {
+	case OverflowDropOldest:
+		for i := range normal {
+			if oldest := normal[i].Event; oldest != nil {
+				return append(append(normal[:i:i], normal[i+1:]...), r), oldest, true
+			}
+		}
+		// the queue is full of errors, so the new event is dropped
+		return normal, r.Event, true
+	case OverflowDropNewest:
+		return normal, r.Event, true
+	default:
+		return normal, nil, false
+	}
+}
+
+// popResult removes the result that was just delivered
+func popResult(priority, normal []queuedResult) ([]queuedResult, []queuedResult) {
+	if len(priority) > 0 {
+		return priority[1:], normal
+	}
+	return priority, normal[1:]
+}
+
+// isPriorityEvent checks if the event type is one of the configured priority event types
+func (c *Client) isPriorityEvent(event *Event) bool {
+	for _, t := range c.PriorityEventTypes {
+		if event.Type == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package sse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_deliveryQueue(t *testing.T) {
+	c := &Client{PriorityEventTypes: []string{"control"}}
+	eventch := make(chan *Event)
+	errch := make(chan error)
+	q := newDeliveryQueue(Backpressure{QueueSize: 10}, c.isPriorityEvent, nil, nil, eventch, errch, nil, nil)
+
+	q.push(Result{Event: &Event{Type: "data", Data: []byte("1")}})
+	q.push(Result{Event: &Event{Type: "data", Data: []byte("2")}})
+	q.push(Result{Err: errors.New("boom")})
+	q.push(Result{Event: &Event{Type: "control", Data: []byte("revoke")}})
+
+	equals(t, "revoke", string((<-eventch).Data))
+	equals(t, "1", string((<-eventch).Data))
+	equals(t, "2", string((<-eventch).Data))
+	equals(t, "boom", (<-errch).Error())
+
+	q.close()
+}
+
+func Test_deliveryQueue_stop(t *testing.T) {
+	c := &Client{}
+	stop := make(chan struct{})
+	q := newDeliveryQueue(Backpressure{QueueSize: 1}, c.isPriorityEvent, nil, nil, make(chan *Event), make(chan error), stop, nil)
+
+	// nobody receives, so the second push blocks until the queue gives up
+	q.push(Result{Event: &Event{Data: []byte("1")}})
+	close(stop)
+	q.push(Result{Event: &Event{Data: []byte("2")}})
+
+	closed := make(chan struct{})
+	go func() {
+		q.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close blocked after the queue was stopped")
+	}
+}
+
+func Test_enqueue(t *testing.T) {
+	event := func(id, data string) queuedResult {
+		return queuedResult{Result: Result{Event: &Event{LastEventID: id, Data: []byte(data)}}}
+	}
+	boom := queuedResult{Result: Result{Err: errors.New("boom")}}
+	contents := func(queue []queuedResult) []string {
+		var s []string
+		for _, r := range queue {
+			if r.Err != nil {
+				s = append(s, r.Err.Error())
+			} else {
+				s = append(s, string(r.Event.Data))
+			}
+		}
+		return s
+	}
+
+	tests := []struct {
+		testname string
+		bp       Backpressure
+		queue    []queuedResult
+		new      queuedResult
+		expected []string
+		dropped  string
+		ok       bool
+	}{
+		{"BlockRoom", Backpressure{QueueSize: 2}, []queuedResult{event("", "1")}, event("", "2"), []string{"1", "2"}, "", true},
+		{"BlockFull", Backpressure{QueueSize: 1}, []queuedResult{event("", "1")}, event("", "2"), []string{"1"}, "", false},
+		{"ErrorsNeverWait", Backpressure{QueueSize: 1}, []queuedResult{event("", "1")}, boom, []string{"1", "boom"}, "", true},
+		{"DropOldest", Backpressure{QueueSize: 2, Overflow: OverflowDropOldest}, []queuedResult{boom, event("", "1"), event("", "2")}, event("", "3"), []string{"boom", "2", "3"}, "1", true},
+		{"DropOldestOnlyErrors", Backpressure{QueueSize: 1, Overflow: OverflowDropOldest}, []queuedResult{boom}, event("", "1"), []string{"boom"}, "1", true},
+		{"DropNewest", Backpressure{QueueSize: 1, Overflow: OverflowDropNewest}, []queuedResult{event("", "1")}, event("", "2"), []string{"1"}, "2", true},
+		{"Coalesce", Backpressure{QueueSize: 3, Overflow: OverflowCoalesce}, []queuedResult{event("a", "1"), event("b", "2")}, event("a", "3"), []string{"3", "2"}, "", true},
+		{"CoalesceFull", Backpressure{QueueSize: 1, Overflow: OverflowCoalesce}, []queuedResult{event("a", "1")}, event("a", "2"), []string{"2"}, "", true},
+		{"CoalesceFullNoMatch", Backpressure{QueueSize: 1, Overflow: OverflowCoalesce}, []queuedResult{event("a", "1")}, event("b", "2"), []string{"1"}, "", false},
+		{"CoalesceWithoutID", Backpressure{QueueSize: 2, Overflow: OverflowCoalesce}, []queuedResult{event("", "1")}, event("", "2"), []string{"1", "2"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testname, func(t *testing.T) {
+			queue, dropped, ok := enqueue(tt.queue, tt.new, tt.bp)
+			equals(t, tt.expected, contents(queue))
+			if tt.dropped == "" {
+				equals(t, (*Event)(nil), dropped)
+			} else {
+				equals(t, tt.dropped, string(dropped.Data))
+			}
+			equals(t, tt.ok, ok)
+		})
+	}
+}
+
+func Test_deliveryQueue_dropOldest(t *testing.T) {
+	c := &Client{}
+	eventch := make(chan *Event)
+	var dropped []string
+	q := newDeliveryQueue(Backpressure{QueueSize: 2, Overflow: OverflowDropOldest}, c.isPriorityEvent, nil, func(event *Event) { dropped = append(dropped, string(event.Data)) }, eventch, make(chan error), nil, nil)
+
+	// nobody receives while the events are pushed, yet pushing never blocks
+	for _, data := range []string{"1", "2", "3", "4"} {
+		q.push(Result{Event: &Event{Data: []byte(data)}})
+	}
+	go q.close()
+	equals(t, "3", string((<-eventch).Data))
+	equals(t, "4", string((<-eventch).Data))
+	equals(t, []string{"1", "2"}, dropped)
+}
@@ -0,0 +1,159 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder writes a transcript of the raw bytes of a stream along with the
+// time they were received, so the stream can later be played back with its
+// original timing by a Replayer. It is an io.Writer, e.g. to be used as
+// Client.Tee.
+//
+// A transcript has one line per chunk written: the nanoseconds since the first
+// chunk followed by the chunk as a Go quoted string, e.g.
+//
+//	1500000 "data: hello\n\n"
+type Recorder struct {
+	mutex sync.Mutex
+	w     io.Writer
+	start time.Time
+	now   func() time.Time
+}
+
+// NewRecorder creates a Recorder writing a transcript to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, now: time.Now}
+}
+
+// Write records p as a chunk of the transcript
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	if r.start.IsZero() {
+		r.start = now
+	}
+	if _, err := fmt.Fprintf(r.w, "%d %s\n", now.Sub(r.start).Nanoseconds(), strconv.Quote(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Replayer plays a transcript written by a Recorder back as an io.Reader of
+// the raw stream, e.g. to be read by a Decoder
+type Replayer struct {
+	// Speed scales the original timing of the transcript: 1 keeps it, 2 plays
+	// it twice as fast and so on. Zero or less plays it as fast as possible.
+	Speed float64
+
+	scanner *bufio.Scanner
+	start   time.Time
+	pending []byte
+	sleep   func(time.Duration)
+}
+
+// NewReplayer creates a Replayer reading a transcript from r at its original speed
+func NewReplayer(r io.Reader) *Replayer {
+	scanner := bufio.NewScanner(r)
+	// chunks may be much longer than the default line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	return &Replayer{Speed: 1, scanner: scanner, sleep: time.Sleep}
+}
+
+// Read reads the raw stream, waiting for each chunk's time to come
+func (r *Replayer) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next reads the next chunk of the transcript and waits until it is due
+func (r *Replayer) next() error {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	line := r.scanner.Text()
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		return fmt.Errorf("invalid transcript line %q", line)
+	}
+	offset, err := strconv.ParseInt(line[:i], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transcript time %q: %v", line[:i], err)
+	}
+	chunk, err := strconv.Unquote(line[i+1:])
+	if err != nil {
+		return fmt.Errorf("invalid transcript chunk %q: %v", line[i+1:], err)
+	}
+
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+	if r.Speed > 0 {
+		due := r.start.Add(time.Duration(float64(offset) / r.Speed))
+		if wait := time.Until(due); wait > 0 {
+			r.sleep(wait)
+		}
+	}
+
+	r.pending = []byte(chunk)
+	return nil
+}
+
+// Replay plays a transcript written by a Recorder back through a Decoder at
+// speed (see Replayer.Speed), delivering its events like a stream of the
+// Client would, e.g. to reproduce offline how a consumer handled a recorded
+// production stream. Both channels are closed once the transcript has been
+// played or ctx is done; an error reading the transcript is sent after the
+// events channel is closed.
+func Replay(ctx context.Context, transcript io.Reader, speed float64) (<-chan *Event, <-chan error) {
+	replayer := NewReplayer(transcript)
+	replayer.Speed = speed
+	return replay(ctx, replayer)
+}
+
+func replay(ctx context.Context, replayer *Replayer) (<-chan *Event, <-chan error) {
+	eventch := make(chan *Event)
+	errch := make(chan error, 1)
+
+	go func() {
+		defer close(errch)
+		decoder := NewDecoder(replayer)
+		for {
+			event, err := decoder.Decode()
+			if err != nil {
+				close(eventch)
+				if err != io.EOF {
+					errch <- err
+				}
+				return
+			}
+			select {
+			case eventch <- event:
+			case <-ctx.Done():
+				close(eventch)
+				return
+			}
+		}
+	}()
+
+	return eventch, errch
+}
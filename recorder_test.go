@@ -0,0 +1,67 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	var transcript bytes.Buffer
+	recorder := NewRecorder(&transcript)
+
+	now := time.Unix(0, 0)
+	recorder.now = func() time.Time { return now }
+	recorder.Write([]byte("data: one\n\n"))
+	now = now.Add(time.Second)
+	recorder.Write([]byte("data: \"two\"\n\n"))
+
+	equals(t, "0 \"data: one\\n\\n\"\n1000000000 \"data: \\\"two\\\"\\n\\n\"\n", transcript.String())
+}
+
+func TestReplayer(t *testing.T) {
+	transcript := "0 \"data: one\\n\\n\"\n1000000000 \"data: two\\n\\n\"\n2000000000 \"data: three\\n\\n\"\n"
+
+	tests := []struct {
+		testname string
+		speed    float64
+		maxSleep time.Duration
+	}{
+		{"original speed", 1, 2 * time.Second},
+		{"ten times faster", 10, 200 * time.Millisecond},
+		{"as fast as possible", 0, 0},
+	}
+
+	for _, test := range tests {
+		replayer := NewReplayer(bytes.NewBufferString(transcript))
+		replayer.Speed = test.speed
+		var slept time.Duration
+		replayer.sleep = func(d time.Duration) { slept = d }
+
+		raw, err := ioutil.ReadAll(replayer)
+		ok(t, err)
+		equals(t, "data: one\n\ndata: two\n\ndata: three\n\n", string(raw))
+		assert(t, slept <= test.maxSleep && (test.maxSleep == 0 || slept > test.maxSleep/2), "%s: last wait %s", test.testname, slept)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	transcript := "0 \"id: 1\\ndata: o\"\n5 \"ne\\n\\ndata: two\\n\\n\"\nbroken\n"
+
+	replayer := NewReplayer(bytes.NewBufferString(transcript))
+	replayer.sleep = func(time.Duration) {}
+	eventch, errch := replay(context.Background(), replayer)
+
+	var events []*Event
+	for event := range eventch {
+		events = append(events, event)
+	}
+	equals(t, []*Event{{LastEventID: "1", Data: []byte("one")}, {Data: []byte("two")}}, events)
+	err := <-errch
+	assert(t, err != nil && strings.Contains(err.Error(), "invalid transcript line"), "unexpected error %v", err)
+	_, open := <-errch
+	equals(t, false, open)
+}
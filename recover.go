@@ -0,0 +1,33 @@
+package sse
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error a stream ends with when its goroutine panicked,
+// e.g. in a hook of the Client, instead of crashing the process
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the goroutine when it panicked
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("stream panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value passed to panic if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic recovers a panic of the stream goroutine into err, to be
+// deferred
+func recoverPanic(err *error) {
+	if value := recover(); value != nil {
+		*err = &PanicError{Value: value, Stack: debug.Stack()}
+	}
+}
@@ -0,0 +1,61 @@
+package sse
+
+import (
+	"context"
+	"time"
+)
+
+// RedisClient is the part of a Redis client a RedisEventIDStore uses,
+// usually a thin wrapper of the driver's client, e.g. for go-redis:
+//
+//	func (c client) Get(ctx context.Context, key string) (string, error) {
+//		id, err := c.rdb.Get(ctx, key).Result()
+//		if err == redis.Nil {
+//			return "", nil
+//		}
+//		return id, err
+//	}
+//
+//	func (c client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+//		return c.rdb.Set(ctx, key, value, ttl).Err()
+//	}
+type RedisClient interface {
+	// Get returns the value of key, empty if it doesn't exist
+	Get(ctx context.Context, key string) (string, error)
+	// Set sets the value of key, expiring after ttl unless it is zero
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// RedisEventIDStore is an EventIDStore keeping IDs in Redis, so consumers
+// scaled horizontally share the positions their streams resume from
+type RedisEventIDStore struct {
+	Client RedisClient
+	// Prefix is prepended to the key of every stream, e.g. "myapp:sse:"
+	Prefix string
+	// TTL, if set, expires the IDs of streams that stopped receiving events
+	TTL time.Duration
+	// Timeout, if set, bounds every call to Redis
+	Timeout time.Duration
+}
+
+// context returns the context of a call to Redis
+func (s *RedisEventIDStore) context() (context.Context, context.CancelFunc) {
+	if s.Timeout > 0 {
+		return context.WithTimeout(context.Background(), s.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// Load implements EventIDStore
+func (s *RedisEventIDStore) Load(streamKey string) (string, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.Get(ctx, s.Prefix+streamKey)
+}
+
+// Save implements EventIDStore
+func (s *RedisEventIDStore) Save(streamKey, id string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.Set(ctx, s.Prefix+streamKey, id, s.TTL)
+}
@@ -0,0 +1,47 @@
+package sse
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a RedisClient keeping values in a map
+type fakeRedis struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+	// deadlines are whether each call had a deadline
+	deadlines []bool
+}
+
+func (r *fakeRedis) Get(ctx context.Context, key string) (string, error) {
+	_, ok := ctx.Deadline()
+	r.deadlines = append(r.deadlines, ok)
+	return r.values[key], nil
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	_, ok := ctx.Deadline()
+	r.deadlines = append(r.deadlines, ok)
+	r.values[key] = value
+	r.ttls[key] = ttl
+	return nil
+}
+
+func TestRedisEventIDStore(t *testing.T) {
+	redis := &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+	store := &RedisEventIDStore{Client: redis, Prefix: "app:sse:", TTL: time.Hour, Timeout: time.Second}
+
+	id, err := store.Load("feed")
+	ok(t, err)
+	equals(t, "", id)
+
+	ok(t, store.Save("feed", "42"))
+	equals(t, "42", redis.values["app:sse:feed"])
+	equals(t, time.Hour, redis.ttls["app:sse:feed"])
+
+	id, err = store.Load("feed")
+	ok(t, err)
+	equals(t, "42", id)
+	equals(t, []bool{true, true, true}, redis.deadlines)
+}
@@ -0,0 +1,59 @@
+package sse
+
+import "net/http"
+
+// Result holds either an event or an error received from a stream
+type Result struct {
+	Event *Event
+	Err   error
+}
+
+// StreamResults is like Stream but delivers events and errors in order on a
+// single channel, so an error can't be missed while waiting for an event.
+// The channel is closed once the stream has terminated, so the terminal error
+// (e.g. ErrStreamIsClosed) is always the last Result received before the
+// close. Cancel the request's context to stop the stream; results not yet
+// received by then are dropped. opts override the configuration of the
+// Client for this stream.
+func (c *Client) StreamResults(req *http.Request, opts ...StreamOption) <-chan Result {
+	req = applyStreamOptions(req, opts)
+	eventch, errch, done := c.startStream(req)
+	resultch := make(chan Result)
+
+	go func() {
+		defer close(resultch)
+
+		// a consumer that stopped reading once the context is done doesn't
+		// keep the goroutine around
+		send := func(r Result) {
+			select {
+			case resultch <- r:
+			case <-req.Context().Done():
+				r.Event.Release()
+			}
+		}
+
+		// the stream goroutine sends synchronously, so everything it sent
+		// has been received by the time done is closed
+		for {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				send(Result{Event: event})
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				send(Result{Err: err})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return resultch
+}
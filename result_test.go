@@ -0,0 +1,87 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_StreamResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	resultch := NewClient(server.Client()).StreamResults(req)
+
+	result, open := <-resultch
+	assert(t, open, "result channel closed before the terminal error")
+	assert(t, result.Err != nil, "expected an error result, got %#v", result)
+
+	_, open = <-resultch
+	assert(t, !open, "result channel should be closed after the terminal error")
+}
+
+func TestClient_StreamResults_cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: hello\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+
+	resultch := NewClient(server.Client()).StreamResults(req.WithContext(ctx))
+
+	result := <-resultch
+	ok(t, result.Err)
+	equals(t, "hello", string(result.Event.Data))
+
+	cancel()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, open := <-resultch:
+			if !open {
+				return
+			}
+		case <-timeout:
+			t.Fatal("result channel wasn't closed after cancelling the context")
+		}
+	}
+}
+
+func TestClient_StreamResults_order(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\nevent: error\ndata: 2\n\ndata: 3\n\nevent: skipped\ndata: 4\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	c := NewClient(server.Client())
+	c.ErrorEventTypes = []string{"error"}
+
+	var results []string
+	for result := range c.StreamResults(req, StreamEventFilter(func(event *Event) bool { return event.Type != "skipped" })) {
+		if result.Event != nil {
+			results = append(results, string(result.Event.Data))
+		} else {
+			results = append(results, result.Err.Error())
+		}
+	}
+	equals(t, []string{"1", newEventError(&Event{Type: "error", Data: []byte("2")}).Error(), "3", ErrStreamIsClosed.Error()}, results)
+}
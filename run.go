@@ -0,0 +1,42 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+)
+
+// Run streams req, calling handler with every event, and blocks until the
+// stream ends. It suits running a stream as part of an errgroup.Group:
+//
+//	group.Go(func() error { return client.Run(ctx, req, handle) })
+//
+// Run returns the error of handler, which stops the stream, ctx's error once
+// ctx is done, or else the error the stream terminated with, nil if it was
+// stopped otherwise, e.g. by StopStream. Errors the stream reconnects after
+// don't end Run. The request's context still applies, as do opts, which
+// override the configuration of the Client for this stream.
+func (c *Client) Run(ctx context.Context, req *http.Request, handler func(*Event) error, opts ...StreamOption) error {
+	stream := c.Connect(req, opts...)
+	defer stream.Close()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-stream.Done():
+		}
+	}()
+
+	for event := range stream.Events() {
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	return req.Context().Err()
+}
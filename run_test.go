@@ -0,0 +1,53 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\ndata: 2\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	c := NewClient(server.Client())
+
+	// the handler's error stops the stream
+	errHandler := errors.New("handler failed")
+	var data []string
+	err = c.Run(context.Background(), req, func(event *Event) error {
+		data = append(data, string(event.Data))
+		if len(data) == 2 {
+			return errHandler
+		}
+		return nil
+	})
+	equals(t, errHandler, err)
+	equals(t, []string{"1", "2"}, data)
+
+	// so does the context
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	equals(t, context.Canceled, c.Run(ctx, req, func(*Event) error { return nil }))
+}
+
+func TestClient_Run_terminalError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	err = NewClient(server.Client()).Run(context.Background(), req, func(*Event) error { return nil })
+	equals(t, ErrStreamIsClosed, err)
+}
@@ -0,0 +1,132 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ErrUnknownSchema is returned by SchemaRegistry.Decode for events whose type
+// or version has no registered schema
+var ErrUnknownSchema = errors.New("unknown schema")
+
+// Schema is one version of the payload of an event type
+type Schema struct {
+	Version int
+	// New returns a pointer to a new value to decode payloads of this version into
+	New func() interface{}
+	// Upgrade converts a decoded value of this version into a value of the next
+	// registered version. It is required for every version but the latest.
+	Upgrade func(value interface{}) (interface{}, error)
+}
+
+// SchemaRegistry maps event types to the versions of their payload, so
+// consumers always get values of the latest version, however old the payload
+// that was received. Older payloads are decoded with their own schema and
+// upgraded version by version.
+type SchemaRegistry struct {
+	// Codec decodes payloads, JSONCodec if nil
+	Codec Codec
+	// VersionPath is the dot separated JSON path of the version in payloads,
+	// "version" if empty. Payloads without a version are of the oldest
+	// registered version.
+	VersionPath string
+	// Version, if set, gets the version of an event's payload instead of
+	// VersionPath, e.g. for payloads that aren't JSON
+	Version func(event *Event) (version int, ok bool)
+
+	mutex   sync.RWMutex
+	schemas map[string][]Schema
+}
+
+// NewSchemaRegistry creates an empty SchemaRegistry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[string][]Schema)}
+}
+
+// Register adds a version of the payload of eventType, replacing any schema
+// previously registered for that version
+func (r *SchemaRegistry) Register(eventType string, schema Schema) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	schemas := r.schemas[eventType]
+	for i := range schemas {
+		if schemas[i].Version == schema.Version {
+			schemas[i] = schema
+			return
+		}
+	}
+	schemas = append(schemas, schema)
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Version < schemas[j].Version })
+	r.schemas[eventType] = schemas
+}
+
+// Decode decodes the data of event with the schema of its version and
+// upgrades it to the latest registered version
+func (r *SchemaRegistry) Decode(event *Event) (interface{}, error) {
+	r.mutex.RLock()
+	schemas := r.schemas[event.Type]
+	r.mutex.RUnlock()
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("%v: no schema for %s events", ErrUnknownSchema, event.Type)
+	}
+
+	codec := r.Codec
+	if codec == nil {
+		codec = defaultCodec
+	}
+	if codec == nil {
+		return nil, errors.New("no codec to decode payloads with")
+	}
+
+	version, hasVersion, err := r.version(event)
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	if hasVersion {
+		i = sort.Search(len(schemas), func(j int) bool { return schemas[j].Version >= version })
+		if i == len(schemas) || schemas[i].Version != version {
+			return nil, fmt.Errorf("%v: no version %d of %s events", ErrUnknownSchema, version, event.Type)
+		}
+	}
+
+	value := schemas[i].New()
+	if err := codec.Decode(event.Data, value); err != nil {
+		return nil, fmt.Errorf("decoding version %d of %s event: %v", schemas[i].Version, event.Type, err)
+	}
+	for ; i < len(schemas)-1; i++ {
+		if schemas[i].Upgrade == nil {
+			return nil, fmt.Errorf("no upgrade from version %d of %s events", schemas[i].Version, event.Type)
+		}
+		if value, err = schemas[i].Upgrade(value); err != nil {
+			return nil, fmt.Errorf("upgrading version %d of %s event: %v", schemas[i].Version, event.Type, err)
+		}
+	}
+	return value, nil
+}
+
+// version gets the version of the payload of event, if it has one
+func (r *SchemaRegistry) version(event *Event) (int, bool, error) {
+	if r.Version != nil {
+		version, ok := r.Version(event)
+		return version, ok, nil
+	}
+
+	path := r.VersionPath
+	if path == "" {
+		path = "version"
+	}
+	raw, ok := findJSONPath(event.Data, path)
+	if !ok {
+		return 0, false, nil
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid version %q of %s event: %v", raw, event.Type, err)
+	}
+	return version, true, nil
+}
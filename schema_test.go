@@ -0,0 +1,58 @@
+//go:build !tinygo && !sse_minimal
+// +build !tinygo,!sse_minimal
+
+package sse
+
+import (
+	"strings"
+	"testing"
+)
+
+type orderV1 struct {
+	Name string `json:"name"`
+}
+
+type orderV2 struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+}
+
+func TestSchemaRegistry_Decode(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register("order", Schema{
+		Version: 2,
+		New:     func() interface{} { return &orderV2{} },
+	})
+	registry.Register("order", Schema{
+		Version: 1,
+		New:     func() interface{} { return &orderV1{} },
+		Upgrade: func(value interface{}) (interface{}, error) {
+			names := strings.SplitN(value.(*orderV1).Name, " ", 2)
+			return &orderV2{First: names[0], Last: names[1]}, nil
+		},
+	})
+
+	tests := []struct {
+		testname  string
+		event     *Event
+		expected  interface{}
+		shouldErr bool
+	}{
+		{"latest version", &Event{Type: "order", Data: []byte(`{"version":2,"first":"Ada","last":"Lovelace"}`)}, &orderV2{"Ada", "Lovelace"}, false},
+		{"upgraded version", &Event{Type: "order", Data: []byte(`{"version":1,"name":"Ada Lovelace"}`)}, &orderV2{"Ada", "Lovelace"}, false},
+		{"no version is the oldest", &Event{Type: "order", Data: []byte(`{"name":"Ada Lovelace"}`)}, &orderV2{"Ada", "Lovelace"}, false},
+		{"unknown version", &Event{Type: "order", Data: []byte(`{"version":3}`)}, nil, true},
+		{"unknown type", &Event{Type: "invoice", Data: []byte(`{}`)}, nil, true},
+		{"invalid payload", &Event{Type: "order", Data: []byte(`{"version":2,"first":1}`)}, nil, true},
+	}
+
+	for _, test := range tests {
+		value, err := registry.Decode(test.event)
+		if test.shouldErr {
+			assert(t, err != nil, "%s: should've errored but didn't", test.testname)
+			continue
+		}
+		ok(t, err)
+		equals(t, test.expected, value)
+	}
+}
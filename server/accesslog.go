@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// Reasons a connection served by the Broker ended, see AccessLog.Reason
+const (
+	ReasonClientGone   = "client disconnected"
+	ReasonWriteFailed  = "write failed"
+	ReasonUnsubscribed = "subscription closed"
+	ReasonSlowClient   = "slow client disconnected"
+)
+
+// AccessLog describes a connection served by the Broker
+type AccessLog struct {
+	ClientID   string
+	RemoteAddr string
+	UserAgent  string
+	Topics     []string
+	// Start is when the connection was opened
+	Start time.Time
+	// Duration, Events, Bytes and Reason are only set once the connection is closed
+	Duration time.Duration
+	// Events is the number of events sent
+	Events int
+	// Bytes is the number of bytes written
+	Bytes int64
+	// Reason is why the connection ended, one of the Reason constants
+	Reason string
+	// Err is the error a write failed with, if any
+	Err error
+}
+
+// AccessLogger logs the connections served by the Broker
+type AccessLogger interface {
+	// Open is called once a connection is streaming
+	Open(log *AccessLog)
+	// Close is called once a connection has ended
+	Close(log *AccessLog)
+}
+
+// newAccessLog starts the access log of a request
+func newAccessLog(r *http.Request, clientID string, topics []string) *AccessLog {
+	return &AccessLog{
+		ClientID:   clientID,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		Topics:     topics,
+		Start:      time.Now(),
+	}
+}
+
+// countingWriter counts the bytes written to an http.ResponseWriter
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countingWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// recordingLogger sends the logs of closed connections on closed
+type recordingLogger struct {
+	opened chan AccessLog
+	closed chan AccessLog
+}
+
+func (l *recordingLogger) Open(log *AccessLog)  { l.opened <- *log }
+func (l *recordingLogger) Close(log *AccessLog) { l.closed <- *log }
+
+func TestBroker_AccessLogger(t *testing.T) {
+	logger := &recordingLogger{opened: make(chan AccessLog, 1), closed: make(chan AccessLog, 1)}
+	broker := NewBroker()
+	broker.AccessLogger = logger
+	broker.ClientID = func(r *http.Request) string { return r.Header.Get("X-Client") }
+	server := httptest.NewServer(broker)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequest(http.MethodGet, server.URL+"?topic=news", nil)
+	ok(t, err)
+	req.Header.Set("X-Client", "alice")
+	eventch, _ := sse.NewClient(server.Client()).Stream(req.WithContext(ctx))
+
+	opened := <-logger.opened
+	equals(t, "alice", opened.ClientID)
+	equals(t, []string{"news"}, opened.Topics)
+
+	broker.Publish("news", &sse.Event{Data: []byte("hello")})
+	<-eventch
+	cancel()
+
+	select {
+	case closed := <-logger.closed:
+		equals(t, 1, closed.Events)
+		equals(t, int64(len("data: hello\n\n")), closed.Bytes)
+		equals(t, ReasonClientGone, closed.Reason)
+		assert(t, closed.Duration > 0, "duration should be set")
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection wasn't logged as closed")
+	}
+}
@@ -0,0 +1,294 @@
+// Package server is the serving side of server-sent events: a Handler
+// streaming events to each connection, a Broker publishing events by topic to
+// the clients connected to it, and adapters feeding it from other systems.
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// DefaultBufferSize is the number of events buffered for each subscriber when
+// Broker.BufferSize isn't set
+const DefaultBufferSize = 64
+
+// SlowPolicy is what a Broker does with a subscriber whose buffer is full
+type SlowPolicy int
+
+const (
+	// DropNewest drops the events published while the buffer is full
+	DropNewest SlowPolicy = iota
+	// DropOldest drops the oldest buffered event to make room for the new one
+	DropOldest
+	// Disconnect closes the subscription, ending the client's connection
+	Disconnect
+)
+
+// Broker is the hub of many subscriber connections. It fans out the events
+// published on a topic to every subscriber of that topic, or broadcasts them
+// to everyone. It is an http.Handler streaming the topics listed in the
+// "topic" query parameters of the request to the client.
+type Broker struct {
+	// BufferSize is the number of events buffered for each subscriber,
+	// DefaultBufferSize if zero
+	BufferSize int
+	// SlowPolicy decides what happens to subscribers whose buffer is full,
+	// by default new events are dropped for them
+	SlowPolicy SlowPolicy
+	// Retain, if set, decides which topics retain their most recent event.
+	// New subscribers of a retaining topic receive its retained event before
+	// any live event, for feeds of the current state followed by updates.
+	Retain func(topic string) bool
+	// ClientID, if set, identifies the client of a request served by the
+	// Broker, so it can be added to groups with Join
+	ClientID func(r *http.Request) string
+	// AccessLogger, if set, logs every connection served, see SlogAccessLogger
+	AccessLogger AccessLogger
+	// ReplaySize is the number of recent events with an ID kept for clients
+	// reconnecting with a Last-Event-ID, see SubscribeFrom. Zero disables replay.
+	ReplaySize int
+	// ReplayTTL, if set, is how long events are kept for replay
+	ReplayTTL time.Duration
+
+	mutex       sync.Mutex
+	all         map[*Subscription]struct{}
+	subscribers map[string]map[*Subscription]struct{}
+	retained    map[string]*sse.Event
+	// clients are the subscriptions of every client ID
+	clients map[string]map[*Subscription]struct{}
+	// groups are the client IDs of every group
+	groups map[string]map[string]struct{}
+	replay replayBuffer
+}
+
+// NewBroker creates a Broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{
+		all:         make(map[*Subscription]struct{}),
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		retained:    make(map[string]*sse.Event),
+		clients:     make(map[string]map[*Subscription]struct{}),
+		groups:      make(map[string]map[string]struct{}),
+	}
+}
+
+// Subscription receives the events published on its topics, and to the
+// groups of its client
+type Subscription struct {
+	broker   *Broker
+	clientID string
+	topics   []string
+	events   chan *sse.Event
+	// closed and slow are guarded by the broker's mutex
+	closed bool
+	slow   bool
+}
+
+// Subscribe subscribes to the events published on topics
+func (b *Broker) Subscribe(topics ...string) *Subscription {
+	return b.SubscribeClient("", topics...)
+}
+
+// SubscribeClient subscribes to the events published on topics on behalf of
+// the client clientID, which also receives the events published to its groups.
+// An empty clientID is an anonymous client that can't join groups.
+func (b *Broker) SubscribeClient(clientID string, topics ...string) *Subscription {
+	return b.SubscribeFrom(clientID, "", topics...)
+}
+
+// SubscribeFrom is SubscribeClient for a client resuming after the event
+// lastEventID: the events of its topics published since then that are still
+// kept for replay (see ReplaySize) are received before live events. Nothing is
+// replayed if lastEventID isn't kept anymore.
+func (b *Broker) SubscribeFrom(clientID, lastEventID string, topics ...string) *Subscription {
+	size := b.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var replay []*sse.Event
+	resumed := false
+	if lastEventID != "" {
+		replay, resumed = b.replay.since(lastEventID, topics, b.ReplayTTL)
+	}
+	sub := &Subscription{broker: b, clientID: clientID, topics: topics, events: make(chan *sse.Event, size+len(replay))}
+
+	b.all[sub] = struct{}{}
+	if clientID != "" {
+		if b.clients[clientID] == nil {
+			b.clients[clientID] = make(map[*Subscription]struct{})
+		}
+		b.clients[clientID][sub] = struct{}{}
+	}
+	for _, topic := range topics {
+		if b.subscribers[topic] == nil {
+			b.subscribers[topic] = make(map[*Subscription]struct{})
+		}
+		b.subscribers[topic][sub] = struct{}{}
+		// a resumed client already got the retained event before it left
+		if event, ok := b.retained[topic]; ok && !resumed {
+			sub.deliver(event)
+		}
+	}
+	for _, event := range replay {
+		sub.deliver(event)
+	}
+	return sub
+}
+
+// Events returns the events published on the topics of the subscription.
+// It is closed once the subscription is closed.
+func (s *Subscription) Events() <-chan *sse.Event {
+	return s.events
+}
+
+// Close unsubscribes from every topic of the subscription
+func (s *Subscription) Close() {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+	s.unsubscribe()
+}
+
+// Slow reports whether the subscription was closed by the Disconnect policy
+func (s *Subscription) Slow() bool {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+	return s.slow
+}
+
+// unsubscribe closes the subscription, it must be called with the broker's mutex held
+func (s *Subscription) unsubscribe() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	b := s.broker
+	delete(b.all, s)
+	for _, topic := range s.topics {
+		delete(b.subscribers[topic], s)
+		if len(b.subscribers[topic]) == 0 {
+			delete(b.subscribers, topic)
+		}
+	}
+	if s.clientID != "" {
+		delete(b.clients[s.clientID], s)
+		if len(b.clients[s.clientID]) == 0 {
+			delete(b.clients, s.clientID)
+		}
+	}
+	close(s.events)
+}
+
+// Publish sends event to every subscriber of topic. It never blocks:
+// subscribers whose buffer is full are handled according to the SlowPolicy.
+func (b *Broker) Publish(topic string, event *sse.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.Retain != nil && b.Retain(topic) {
+		b.retained[topic] = event
+	}
+	b.replay.add(b.ReplaySize, topic, event)
+
+	for sub := range b.subscribers[topic] {
+		sub.deliver(event)
+	}
+}
+
+// Broadcast sends event to every subscriber, whatever its topics. Like
+// Publish, it never blocks.
+func (b *Broker) Broadcast(event *sse.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.replay.add(b.ReplaySize, broadcastTopic, event)
+	for sub := range b.all {
+		sub.deliver(event)
+	}
+}
+
+// deliver sends an event to the subscription, applying the SlowPolicy if its
+// buffer is full. It must be called with the broker's mutex held.
+func (s *Subscription) deliver(event *sse.Event) {
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- event:
+		return
+	default:
+	}
+
+	switch s.broker.SlowPolicy {
+	case DropOldest:
+		select {
+		case <-s.events:
+		default:
+		}
+		select {
+		case s.events <- event:
+		default:
+		}
+	case Disconnect:
+		s.slow = true
+		s.unsubscribe()
+	}
+}
+
+// ClearRetained forgets the retained event of topic
+func (b *Broker) ClearRetained(topic string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.retained, topic)
+}
+
+// ServeHTTP streams the events of the topics in the "topic" query parameters,
+// and of the groups of the client, until the client disconnects
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, ErrStreamingUnsupported.Error(), http.StatusInternalServerError)
+		return
+	}
+	var clientID string
+	if b.ClientID != nil {
+		clientID = b.ClientID(r)
+	}
+	// identified clients may only want the events of their groups
+	topics := r.URL.Query()["topic"]
+	if len(topics) == 0 && clientID == "" {
+		http.Error(w, "no topic", http.StatusBadRequest)
+		return
+	}
+	sub := b.SubscribeFrom(clientID, r.Header.Get("Last-Event-ID"), topics...)
+	defer sub.Close()
+
+	counter := &countingWriter{ResponseWriter: w}
+	log := newAccessLog(r, clientID, topics)
+	if b.AccessLogger != nil {
+		b.AccessLogger.Open(log)
+		defer func() {
+			log.Duration = time.Since(log.Start)
+			log.Bytes = counter.n
+			b.AccessLogger.Close(log)
+		}()
+	}
+
+	err := serve(counter, r, sub.Events(), func() { log.Events++ })
+	switch {
+	case err == nil && sub.Slow():
+		log.Reason = ReasonSlowClient
+	case err == nil:
+		log.Reason = ReasonUnsubscribed
+	case r.Context().Err() != nil:
+		log.Reason = ReasonClientGone
+	default:
+		log.Reason, log.Err = ReasonWriteFailed, err
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	broker := NewBroker()
+	broker.BufferSize = 1
+	news := broker.Subscribe("news")
+	all := broker.Subscribe("news", "sports")
+
+	broker.Publish("news", &sse.Event{Data: []byte("1")})
+	broker.Publish("sports", &sse.Event{Data: []byte("2")})
+
+	equals(t, "1", string((<-news.Events()).Data))
+	// the buffer of all was full, so the second event was dropped
+	equals(t, "1", string((<-all.Events()).Data))
+	select {
+	case event := <-all.Events():
+		t.Fatalf("unexpected event %q", event.Data)
+	default:
+	}
+
+	news.Close()
+	news.Close()
+	_, open := <-news.Events()
+	assert(t, !open, "events should be closed after Close")
+	broker.Publish("news", &sse.Event{Data: []byte("3")})
+	equals(t, "3", string((<-all.Events()).Data))
+}
+
+func TestBroker_Broadcast(t *testing.T) {
+	broker := NewBroker()
+	news := broker.Subscribe("news")
+	sports := broker.Subscribe("sports")
+	alice := broker.SubscribeClient("alice")
+
+	broker.Broadcast(&sse.Event{Data: []byte("maintenance")})
+	for _, sub := range []*Subscription{news, sports, alice} {
+		equals(t, "maintenance", string((<-sub.Events()).Data))
+	}
+}
+
+func TestBroker_SlowPolicy(t *testing.T) {
+	tests := []struct {
+		testname string
+		policy   SlowPolicy
+		expected []string
+		slow     bool
+	}{
+		{"drop newest", DropNewest, []string{"1", "2"}, false},
+		{"drop oldest", DropOldest, []string{"2", "3"}, false},
+		{"disconnect", Disconnect, []string{"1", "2"}, true},
+	}
+
+	for _, test := range tests {
+		broker := NewBroker()
+		broker.BufferSize = 2
+		broker.SlowPolicy = test.policy
+		sub := broker.Subscribe("news")
+
+		for _, data := range []string{"1", "2", "3"} {
+			broker.Publish("news", &sse.Event{Data: []byte(data)})
+		}
+		if test.slow {
+			// the buffered events are still received before the channel closes
+			broker.Publish("news", &sse.Event{Data: []byte("4")})
+		} else {
+			sub.Close()
+		}
+
+		var received []string
+		for event := range sub.Events() {
+			received = append(received, string(event.Data))
+		}
+		equals(t, test.expected, received)
+		assert(t, sub.Slow() == test.slow, "%s: expected slow to be %v", test.testname, test.slow)
+	}
+}
+
+func TestBroker_Retain(t *testing.T) {
+	broker := NewBroker()
+	broker.Retain = func(topic string) bool { return topic == "state" }
+
+	broker.Publish("state", &sse.Event{Data: []byte("1")})
+	broker.Publish("state", &sse.Event{Data: []byte("2")})
+	broker.Publish("updates", &sse.Event{Data: []byte("3")})
+
+	sub := broker.Subscribe("state", "updates")
+	equals(t, "2", string((<-sub.Events()).Data))
+	broker.Publish("updates", &sse.Event{Data: []byte("4")})
+	equals(t, "4", string((<-sub.Events()).Data))
+
+	broker.ClearRetained("state")
+	select {
+	case event := <-broker.Subscribe("state").Events():
+		t.Fatalf("unexpected event %q", event.Data)
+	default:
+	}
+}
+
+func TestBroker_ServeHTTP(t *testing.T) {
+	broker := NewBroker()
+	server := httptest.NewServer(broker)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL+"?topic=news", nil)
+	ok(t, err)
+
+	eventch, _ := sse.NewClient(server.Client()).Stream(req.WithContext(ctx))
+	// publish until the client is subscribed
+	go func() {
+		for ctx.Err() == nil {
+			broker.Publish("news", &sse.Event{LastEventID: "1", Type: "headline", Data: []byte("line 1\nline 2")})
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	event := <-eventch
+	equals(t, "1", event.LastEventID)
+	equals(t, "headline", event.Type)
+	equals(t, "line 1\nline 2", string(event.Data))
+}
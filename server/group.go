@@ -0,0 +1,71 @@
+package server
+
+import sse "github.com/mellena1/sse-client-go"
+
+// Groups ("rooms") are sets of clients events can be published to, independent
+// of the topics they subscribed to. Membership belongs to the client ID rather
+// than to a connection, so it outlives reconnects until the client leaves.
+
+// Join adds the client clientID to group
+func (b *Broker) Join(clientID, group string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.join(clientID, group)
+}
+
+// Leave removes the client clientID from group
+func (b *Broker) Leave(clientID, group string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.leave(clientID, group)
+}
+
+// Move moves the client clientID from one group to another, so no event
+// published to either group is missed or received twice in between
+func (b *Broker) Move(clientID, from, to string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.leave(clientID, from)
+	b.join(clientID, to)
+}
+
+// Members returns the client IDs of the members of group
+func (b *Broker) Members(group string) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	members := make([]string, 0, len(b.groups[group]))
+	for clientID := range b.groups[group] {
+		members = append(members, clientID)
+	}
+	return members
+}
+
+// PublishToGroup sends event to every connection of every member of group.
+// Like Publish, it never blocks.
+func (b *Broker) PublishToGroup(group string, event *sse.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for clientID := range b.groups[group] {
+		for sub := range b.clients[clientID] {
+			sub.deliver(event)
+		}
+	}
+}
+
+// join adds a client to a group, it must be called with the mutex held
+func (b *Broker) join(clientID, group string) {
+	if b.groups[group] == nil {
+		b.groups[group] = make(map[string]struct{})
+	}
+	b.groups[group][clientID] = struct{}{}
+}
+
+// leave removes a client from a group, it must be called with the mutex held
+func (b *Broker) leave(clientID, group string) {
+	delete(b.groups[group], clientID)
+	if len(b.groups[group]) == 0 {
+		delete(b.groups, group)
+	}
+}
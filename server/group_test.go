@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+func TestBroker_PublishToGroup(t *testing.T) {
+	broker := NewBroker()
+	// alice is connected twice, e.g. from two tabs
+	alice1 := broker.SubscribeClient("alice")
+	alice2 := broker.SubscribeClient("alice")
+	bob := broker.SubscribeClient("bob", "news")
+
+	broker.Join("alice", "lobby")
+	broker.Join("bob", "lobby")
+	equals(t, 2, len(broker.Members("lobby")))
+
+	broker.PublishToGroup("lobby", &sse.Event{Data: []byte("welcome")})
+	for _, sub := range []*Subscription{alice1, alice2, bob} {
+		equals(t, "welcome", string((<-sub.Events()).Data))
+	}
+
+	broker.Move("bob", "lobby", "room-1")
+	broker.PublishToGroup("lobby", &sse.Event{Data: []byte("lobby only")})
+	broker.PublishToGroup("room-1", &sse.Event{Data: []byte("room only")})
+	equals(t, "lobby only", string((<-alice1.Events()).Data))
+	equals(t, "room only", string((<-bob.Events()).Data))
+
+	// membership outlives the connections of a client
+	alice1.Close()
+	alice2.Close()
+	alice3 := broker.SubscribeClient("alice")
+	broker.PublishToGroup("lobby", &sse.Event{Data: []byte("again")})
+	equals(t, "again", string((<-alice3.Events()).Data))
+
+	broker.Leave("alice", "lobby")
+	equals(t, 0, len(broker.Members("lobby")))
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// ErrStreamingUnsupported is returned by Serve when the http.ResponseWriter
+// can't flush, so events would be held back by buffering
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
+
+// Handler is an http.Handler streaming the events of a channel to each
+// connection. The function returns the channel of a request's connection,
+// which is streamed until it is closed or the client disconnects, or nil if
+// it has written a response itself, e.g. to reject the request.
+type Handler func(w http.ResponseWriter, r *http.Request) <-chan *sse.Event
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, ErrStreamingUnsupported.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events := h(w, r); events != nil {
+		Serve(w, r, events)
+	}
+}
+
+// Serve streams events to the client of r: it sets the SSE headers and
+// flushes every event as soon as it is written. It returns nil once events is
+// closed, the request context's error once the client has disconnected, or
+// the error writing to the client failed with.
+func Serve(w http.ResponseWriter, r *http.Request, events <-chan *sse.Event) error {
+	return serve(w, r, events, nil)
+}
+
+// serve is Serve, calling sent after every event sent
+func serve(w http.ResponseWriter, r *http.Request, events <-chan *sse.Event, sent func()) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return ErrStreamingUnsupported
+	}
+
+	encoder := sse.NewEncoder(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+			flusher.Flush()
+			if sent != nil {
+				sent()
+			}
+		case <-r.Context().Done():
+			return r.Context().Err()
+		}
+	}
+}
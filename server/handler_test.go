@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+func TestHandler(t *testing.T) {
+	handler := Handler(func(w http.ResponseWriter, r *http.Request) <-chan *sse.Event {
+		if r.URL.Query().Get("token") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return nil
+		}
+		events := make(chan *sse.Event, 2)
+		events <- &sse.Event{Type: "greeting", Data: []byte("hello")}
+		events <- &sse.Event{Data: []byte("line 1\nline 2")}
+		close(events)
+		return events
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	ok(t, err)
+	resp.Body.Close()
+	equals(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "?token=secret")
+	ok(t, err)
+	defer resp.Body.Close()
+	equals(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	decoder := sse.NewDecoder(resp.Body)
+	event, err := decoder.Decode()
+	ok(t, err)
+	equals(t, &sse.Event{Type: "greeting", Data: []byte("hello")}, event)
+	event, err = decoder.Decode()
+	ok(t, err)
+	equals(t, &sse.Event{Data: []byte("line 1\nline 2")}, event)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// DefaultMaxIngestBodySize is the maximum size of a request body accepted by
+// an IngestHandler when MaxBodySize isn't set
+const DefaultMaxIngestBodySize = 1 << 20
+
+// IngestedEvent is the JSON body of a request to an IngestHandler. Data is
+// sent as is when it is a JSON string and as its JSON encoding otherwise.
+type IngestedEvent struct {
+	Topic string          `json:"topic"`
+	ID    string          `json:"id,omitempty"`
+	Event string          `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// IngestHandler is an http.Handler accepting events POSTed by external systems
+// and publishing them to a Broker. The body is either a single IngestedEvent
+// or an array of them.
+type IngestHandler struct {
+	Broker *Broker
+	// Authenticate, if set, is called for every request before reading it.
+	// Requests it returns an error for are rejected with 401 Unauthorized.
+	Authenticate func(r *http.Request) error
+	// MaxBodySize is the maximum size of a body, DefaultMaxIngestBodySize if zero
+	MaxBodySize int64
+}
+
+func (h *IngestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Authenticate != nil {
+		if err := h.Authenticate(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	size := h.MaxBodySize
+	if size <= 0 {
+		size = DefaultMaxIngestBodySize
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, size))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ingested, err := parseIngested(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, in := range ingested {
+		h.Broker.Publish(in.Topic, in.event())
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseIngested parses a single event or an array of them, all of which need a topic
+func parseIngested(body []byte) ([]IngestedEvent, error) {
+	var ingested []IngestedEvent
+	if err := json.Unmarshal(body, &ingested); err != nil {
+		var single IngestedEvent
+		if err := json.Unmarshal(body, &single); err != nil {
+			return nil, err
+		}
+		ingested = []IngestedEvent{single}
+	}
+
+	for _, in := range ingested {
+		if in.Topic == "" {
+			return nil, errors.New("event has no topic")
+		}
+	}
+	return ingested, nil
+}
+
+// event maps an ingested event to the event published
+func (in IngestedEvent) event() *sse.Event {
+	data := []byte(in.Data)
+	var s string
+	if err := json.Unmarshal(in.Data, &s); err == nil {
+		data = []byte(s)
+	}
+	return &sse.Event{LastEventID: in.ID, Type: in.Event, Data: data}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+func TestIngestHandler(t *testing.T) {
+	broker := NewBroker()
+	sub := broker.Subscribe("orders")
+	handler := &IngestHandler{
+		Broker: broker,
+		Authenticate: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+	}
+
+	tests := []struct {
+		testname string
+		method   string
+		token    string
+		body     string
+		status   int
+		expected []*sse.Event
+	}{
+		{"single event", http.MethodPost, "secret", `{"topic":"orders","id":"1","event":"created","data":"order 1"}`, http.StatusAccepted,
+			[]*sse.Event{{LastEventID: "1", Type: "created", Data: []byte("order 1")}}},
+		{"json data", http.MethodPost, "secret", `[{"topic":"orders","data":{"id":2}},{"topic":"orders","data":3}]`, http.StatusAccepted,
+			[]*sse.Event{{Data: []byte(`{"id":2}`)}, {Data: []byte("3")}}},
+		{"no topic", http.MethodPost, "secret", `{"data":"x"}`, http.StatusBadRequest, nil},
+		{"invalid json", http.MethodPost, "secret", `{`, http.StatusBadRequest, nil},
+		{"unauthenticated", http.MethodPost, "wrong", `{"topic":"orders","data":"x"}`, http.StatusUnauthorized, nil},
+		{"wrong method", http.MethodGet, "secret", ``, http.StatusMethodNotAllowed, nil},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/ingest", strings.NewReader(test.body))
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert(t, rec.Code == test.status, "%s: expected status %d, got %d", test.testname, test.status, rec.Code)
+		for _, expected := range test.expected {
+			equals(t, expected, <-sub.Events())
+		}
+	}
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("unexpected event %q", event.Data)
+	default:
+	}
+}
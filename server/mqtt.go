@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// DefaultMQTTReconnectDelay is the delay between connection attempts of an
+// MQTTBridge when ReconnectDelay isn't set
+const DefaultMQTTReconnectDelay = 3 * time.Second
+
+// MQTTMessage is a message received from an MQTT broker
+type MQTTMessage struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	// Ack, if set, acknowledges a QoS 1 or 2 message. It is called once the
+	// message has been published to the Broker.
+	Ack func()
+}
+
+// MQTTConn is a connection to an MQTT broker, usually a thin wrapper of the
+// MQTT library's client (e.g. paho.mqtt.golang) with manual acks
+type MQTTConn interface {
+	// Subscribe subscribes to a topic filter, calling handler for every message
+	Subscribe(filter string, qos byte, handler func(*MQTTMessage)) error
+	// Done is closed once the connection is lost
+	Done() <-chan struct{}
+	Close() error
+}
+
+// MQTTBridge republishes the messages of MQTT topics to a Broker, with the
+// payload as the event data, reconnecting whenever the connection is lost
+type MQTTBridge struct {
+	// Dial connects to the MQTT broker
+	Dial func(ctx context.Context) (MQTTConn, error)
+	// Subscriptions maps the topic filters to subscribe to (e.g. "devices/+/telemetry")
+	// to their QoS
+	Subscriptions map[string]byte
+	// Topic maps an MQTT topic to the topic its messages are published on,
+	// the MQTT topic if nil
+	Topic func(mqttTopic string) string
+	// Event, if set, builds the event published for a message
+	Event func(msg *MQTTMessage) *sse.Event
+	// ReconnectDelay is the delay before reconnecting, DefaultMQTTReconnectDelay if zero
+	ReconnectDelay time.Duration
+	// OnError, if set, is called with every connection or subscription error
+	OnError func(err error)
+}
+
+// Run bridges messages to broker until ctx is done
+func (b *MQTTBridge) Run(ctx context.Context, broker *Broker) error {
+	delay := b.ReconnectDelay
+	if delay <= 0 {
+		delay = DefaultMQTTReconnectDelay
+	}
+
+	for {
+		if err := b.session(ctx, broker); err != nil && ctx.Err() == nil && b.OnError != nil {
+			b.OnError(err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// session connects and bridges messages until the connection is lost
+func (b *MQTTBridge) session(ctx context.Context, broker *Broker) error {
+	conn, err := b.Dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for filter, qos := range b.Subscriptions {
+		if err := conn.Subscribe(filter, qos, func(msg *MQTTMessage) { b.publish(broker, msg) }); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-conn.Done():
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// publish publishes a message to the broker, then acknowledges it
+func (b *MQTTBridge) publish(broker *Broker, msg *MQTTMessage) {
+	topic := msg.Topic
+	if b.Topic != nil {
+		topic = b.Topic(msg.Topic)
+	}
+	event := &sse.Event{Data: msg.Payload}
+	if b.Event != nil {
+		event = b.Event(msg)
+	}
+	broker.Publish(topic, event)
+
+	if msg.QoS > 0 && msg.Ack != nil {
+		msg.Ack()
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// fakeMQTTConn is a connection whose messages are delivered with deliver
+type fakeMQTTConn struct {
+	mutex    sync.Mutex
+	handlers map[string]func(*MQTTMessage)
+	done     chan struct{}
+}
+
+func (c *fakeMQTTConn) Subscribe(filter string, qos byte, handler func(*MQTTMessage)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handlers[filter] = handler
+	return nil
+}
+
+func (c *fakeMQTTConn) Done() <-chan struct{} { return c.done }
+func (c *fakeMQTTConn) Close() error          { return nil }
+
+func (c *fakeMQTTConn) deliver(filter string, msg *MQTTMessage) {
+	c.mutex.Lock()
+	handler := c.handlers[filter]
+	c.mutex.Unlock()
+	handler(msg)
+}
+
+func TestMQTTBridge_Run(t *testing.T) {
+	conns := make(chan *fakeMQTTConn, 2)
+	bridge := &MQTTBridge{
+		Dial: func(ctx context.Context) (MQTTConn, error) {
+			conn := &fakeMQTTConn{handlers: make(map[string]func(*MQTTMessage)), done: make(chan struct{})}
+			conns <- conn
+			return conn, nil
+		},
+		Subscriptions:  map[string]byte{"devices/+/telemetry": 1},
+		Topic:          func(mqttTopic string) string { return "telemetry" },
+		ReconnectDelay: time.Millisecond,
+	}
+	broker := NewBroker()
+	sub := broker.Subscribe("telemetry")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errch := make(chan error)
+	go func() { errch <- bridge.Run(ctx, broker) }()
+
+	conn := <-conns
+	acked := make(chan struct{})
+	conn.deliver("devices/+/telemetry", &MQTTMessage{Topic: "devices/1/telemetry", Payload: []byte("21.5"), QoS: 1, Ack: func() { close(acked) }})
+	equals(t, &sse.Event{Data: []byte("21.5")}, <-sub.Events())
+	<-acked
+
+	// a lost connection is reconnected and resubscribed
+	close(conn.done)
+	conn = <-conns
+	for {
+		conn.mutex.Lock()
+		subscribed := conn.handlers["devices/+/telemetry"] != nil
+		conn.mutex.Unlock()
+		if subscribed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	conn.deliver("devices/+/telemetry", &MQTTMessage{Topic: "devices/2/telemetry", Payload: []byte("19")})
+	equals(t, &sse.Event{Data: []byte("19")}, <-sub.Events())
+
+	cancel()
+	equals(t, context.Canceled, <-errch)
+}
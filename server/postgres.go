@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// Notification is a notification received on a Postgres channel
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+// PostgresListener is a Postgres connection receiving notifications, usually
+// a thin wrapper of the driver's connection, e.g. for pgx:
+//
+//	func (l listener) Listen(ctx context.Context, channel string) error {
+//		_, err := l.conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+//		return err
+//	}
+//
+//	func (l listener) WaitForNotification(ctx context.Context) (*server.Notification, error) {
+//		n, err := l.conn.WaitForNotification(ctx)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &server.Notification{Channel: n.Channel, Payload: n.Payload}, nil
+//	}
+type PostgresListener interface {
+	// Listen starts listening on channel (LISTEN channel)
+	Listen(ctx context.Context, channel string) error
+	// WaitForNotification blocks until a notification is received
+	WaitForNotification(ctx context.Context) (*Notification, error)
+}
+
+// PostgresSource publishes the notifications of Postgres channels (LISTEN/NOTIFY)
+// to a Broker, on a topic per channel with the payload as the event data
+type PostgresSource struct {
+	Listener PostgresListener
+	Channels []string
+	// Topic maps a channel to the topic its notifications are published on,
+	// the channel name if nil
+	Topic func(channel string) string
+	// Event, if set, builds the event published for a notification
+	Event func(n *Notification) *sse.Event
+}
+
+// Run listens on the channels and publishes notifications to broker until ctx
+// is done or the listener fails. Reconnecting the listener is left to the caller.
+func (s *PostgresSource) Run(ctx context.Context, broker *Broker) error {
+	for _, channel := range s.Channels {
+		if err := s.Listener.Listen(ctx, channel); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n, err := s.Listener.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		topic := n.Channel
+		if s.Topic != nil {
+			topic = s.Topic(n.Channel)
+		}
+		event := &sse.Event{Data: []byte(n.Payload)}
+		if s.Event != nil {
+			event = s.Event(n)
+		}
+		broker.Publish(topic, event)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// fakeListener delivers notifications sent on a channel
+type fakeListener struct {
+	channels      []string
+	notifications chan *Notification
+}
+
+func (l *fakeListener) Listen(ctx context.Context, channel string) error {
+	l.channels = append(l.channels, channel)
+	return nil
+}
+
+func (l *fakeListener) WaitForNotification(ctx context.Context) (*Notification, error) {
+	select {
+	case n := <-l.notifications:
+		return n, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestPostgresSource_Run(t *testing.T) {
+	listener := &fakeListener{notifications: make(chan *Notification)}
+	source := &PostgresSource{
+		Listener: listener,
+		Channels: []string{"orders"},
+		Topic:    func(channel string) string { return "db." + channel },
+	}
+	broker := NewBroker()
+	sub := broker.Subscribe("db.orders")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errch := make(chan error)
+	go func() { errch <- source.Run(ctx, broker) }()
+
+	listener.notifications <- &Notification{Channel: "orders", Payload: `{"id":1}`}
+	equals(t, &sse.Event{Data: []byte(`{"id":1}`)}, <-sub.Events())
+	equals(t, []string{"orders"}, listener.channels)
+
+	cancel()
+	equals(t, context.Canceled, <-errch)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// broadcastTopic is the topic of broadcast events in the replay buffer,
+// replayed whatever the topics of the client
+const broadcastTopic = "\x00broadcast"
+
+// replayEntry is an event kept for replay
+type replayEntry struct {
+	topic string
+	event *sse.Event
+	at    time.Time
+}
+
+// replayBuffer is a ring buffer of the most recent events with an ID
+type replayBuffer struct {
+	entries []replayEntry
+	// start is the index of the oldest entry once the buffer is full
+	start int
+}
+
+// add keeps an event, evicting the oldest one past size
+func (r *replayBuffer) add(size int, topic string, event *sse.Event) {
+	if size <= 0 || event.LastEventID == "" {
+		return
+	}
+
+	entry := replayEntry{topic: topic, event: event, at: time.Now()}
+	if len(r.entries) < size {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	if len(r.entries) > size {
+		// the size was lowered
+		r.entries = r.ordered()[len(r.entries)-size:]
+		r.start = 0
+	}
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+// ordered returns the entries from the oldest to the newest
+func (r *replayBuffer) ordered() []replayEntry {
+	return append(append([]replayEntry(nil), r.entries[r.start:]...), r.entries[:r.start]...)
+}
+
+// since returns the events of topics kept after the event lastEventID, and
+// whether that event was found
+func (r *replayBuffer) since(lastEventID string, topics []string, ttl time.Duration) ([]*sse.Event, bool) {
+	entries := r.ordered()
+	found := -1
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].event.LastEventID == lastEventID {
+			found = i
+			break
+		}
+	}
+	if found < 0 {
+		return nil, false
+	}
+
+	var events []*sse.Event
+	for _, entry := range entries[found+1:] {
+		if ttl > 0 && time.Since(entry.at) > ttl {
+			continue
+		}
+		if entry.topic == broadcastTopic || containsTopic(topics, entry.topic) {
+			events = append(events, entry.event)
+		}
+	}
+	return events, true
+}
+
+func containsTopic(topics []string, topic string) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+func TestBroker_SubscribeFrom(t *testing.T) {
+	broker := NewBroker()
+	broker.ReplaySize = 3
+	broker.Retain = func(topic string) bool { return true }
+
+	for i := 1; i <= 4; i++ {
+		broker.Publish("news", &sse.Event{LastEventID: strconv.Itoa(i), Data: []byte(strconv.Itoa(i))})
+	}
+	broker.Publish("sports", &sse.Event{LastEventID: "5", Data: []byte("5")})
+	broker.Broadcast(&sse.Event{LastEventID: "6", Data: []byte("6")})
+
+	tests := []struct {
+		testname    string
+		lastEventID string
+		expected    []string
+	}{
+		{"resumed", "4", []string{"6"}},
+		{"evicted", "1", []string{"4"}},
+		{"new client", "", []string{"4"}},
+	}
+
+	for _, test := range tests {
+		sub := broker.SubscribeFrom("", test.lastEventID, "news")
+		sub.Close()
+		var received []string
+		for event := range sub.Events() {
+			received = append(received, string(event.Data))
+		}
+		equals(t, test.expected, received)
+	}
+}
+
+func Test_replayBuffer_since(t *testing.T) {
+	var buffer replayBuffer
+	for i := 1; i <= 5; i++ {
+		buffer.add(3, "news", &sse.Event{LastEventID: strconv.Itoa(i)})
+	}
+	buffer.add(3, "news", &sse.Event{})
+
+	events, found := buffer.since("3", []string{"news"}, 0)
+	assert(t, found, "3 should still be kept")
+	equals(t, []*sse.Event{{LastEventID: "4"}, {LastEventID: "5"}}, events)
+
+	_, found = buffer.since("2", []string{"news"}, 0)
+	assert(t, !found, "2 should've been evicted")
+
+	// the newest event, 5, is the second entry of the ring
+	buffer.entries[1].at = time.Now().Add(-time.Hour)
+	events, _ = buffer.since("3", []string{"news"}, time.Minute)
+	equals(t, []*sse.Event{{LastEventID: "4"}}, events)
+}
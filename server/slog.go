@@ -0,0 +1,48 @@
+//go:build go1.21
+// +build go1.21
+
+package server
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogAccessLogger returns an AccessLogger writing to logger, at the info
+// level, or the warn level for connections ending with a write error
+func SlogAccessLogger(logger *slog.Logger) AccessLogger {
+	return slogAccessLogger{logger}
+}
+
+type slogAccessLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogAccessLogger) Open(log *AccessLog) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, "sse connection opened", l.attrs(log)...)
+}
+
+func (l slogAccessLogger) Close(log *AccessLog) {
+	level := slog.LevelInfo
+	attrs := append(l.attrs(log),
+		slog.Duration("duration", log.Duration),
+		slog.Int("events", log.Events),
+		slog.Int64("bytes", log.Bytes),
+		slog.String("reason", log.Reason),
+	)
+	if log.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", log.Err.Error()))
+	}
+	l.logger.LogAttrs(context.Background(), level, "sse connection closed", attrs...)
+}
+
+// attrs are the attributes of both the open and close records
+func (l slogAccessLogger) attrs(log *AccessLog) []slog.Attr {
+	return []slog.Attr{
+		slog.String("client_id", log.ClientID),
+		slog.String("remote_addr", log.RemoteAddr),
+		slog.String("user_agent", log.UserAgent),
+		slog.Any("topics", log.Topics),
+	}
+}
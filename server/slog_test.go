@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogAccessLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := SlogAccessLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	log := &AccessLog{ClientID: "alice", Topics: []string{"news"}, Start: time.Now()}
+	logger.Open(log)
+	log.Duration, log.Events, log.Bytes, log.Reason = time.Second, 2, 30, ReasonClientGone
+	logger.Close(log)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	equals(t, 2, len(lines))
+	assert(t, strings.Contains(lines[0], `msg="sse connection opened"`) && strings.Contains(lines[0], "client_id=alice"), "unexpected open record %s", lines[0])
+	for _, attr := range []string{`msg="sse connection closed"`, "duration=1s", "events=2", "bytes=30", `reason="client disconnected"`} {
+		assert(t, strings.Contains(lines[1], attr), "close record %s is missing %s", lines[1], attr)
+	}
+}
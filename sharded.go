@@ -0,0 +1,216 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ShardMetadataKey is the metadata key holding the shard index of events
+// delivered by a ShardedSubscription
+const ShardMetadataKey = "shard"
+
+// ShardedSubscription streams every shard of a partitioned feed and merges
+// their events into one channel. Each shard is a separate stream of the Client,
+// so shards reconnect independently when the Client has Reconnect set.
+type ShardedSubscription struct {
+	client      *Client
+	urlTemplate string
+	ctx         context.Context
+
+	resizeMutex sync.Mutex
+
+	mutex   sync.Mutex
+	count   int
+	shards  map[int]*shardStream
+	cursors map[int]string
+	wg      sync.WaitGroup
+
+	events chan *Event
+	errors chan error
+}
+
+// shardStream is a running stream of a single shard
+type shardStream struct {
+	cancel context.CancelFunc
+	// done is closed once the shard's goroutine has exited
+	done chan struct{}
+}
+
+// SubscribeSharded starts streaming count shards of a feed. urlTemplate is
+// the URL of a shard where "{shard}" is replaced by the shard index (0 to count-1)
+// and "{count}" by the number of shards. Every event has its shard index
+// in its Metadata under ShardMetadataKey.
+func (c *Client) SubscribeSharded(ctx context.Context, urlTemplate string, count int) *ShardedSubscription {
+	s := &ShardedSubscription{
+		client:      c,
+		urlTemplate: urlTemplate,
+		ctx:         ctx,
+		shards:      make(map[int]*shardStream),
+		cursors:     make(map[int]string),
+		events:      make(chan *Event),
+		errors:      make(chan error),
+	}
+	s.Resize(count)
+	return s
+}
+
+// Events returns the merged events of all shards
+func (s *ShardedSubscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Errors returns the errors of all shards. Errors of a shard are wrapped in a *ShardError.
+func (s *ShardedSubscription) Errors() <-chan error {
+	return s.errors
+}
+
+// ShardError is an error from the stream of a single shard
+type ShardError struct {
+	Shard int
+	Err   error
+}
+
+func (e *ShardError) Error() string {
+	return "shard " + strconv.Itoa(e.Shard) + ": " + e.Err.Error()
+}
+
+// Cursors returns the last event ID received on each shard
+func (s *ShardedSubscription) Cursors() map[int]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cursors := make(map[int]string, len(s.cursors))
+	for shard, id := range s.cursors {
+		cursors[shard] = id
+	}
+	return cursors
+}
+
+// Resize rebalances the subscription to count shards. Shards past count are
+// stopped and new ones started. If the URL template uses "{count}", every
+// shard is restarted, resuming from its cursor with the Last-Event-ID header.
+// Stopped shards have ended before their replacements start.
+func (s *ShardedSubscription) Resize(count int) {
+	s.resizeMutex.Lock()
+	defer s.resizeMutex.Unlock()
+
+	s.mutex.Lock()
+	restartAll := count != s.count && strings.Contains(s.urlTemplate, "{count}")
+	var stopped []*shardStream
+	for shard, stream := range s.shards {
+		if restartAll || shard >= count {
+			stream.cancel()
+			stopped = append(stopped, stream)
+			delete(s.shards, shard)
+		}
+	}
+	s.mutex.Unlock()
+
+	// the forwarding goroutines take the mutex to update cursors, so they
+	// are waited for without holding it
+	for _, stream := range stopped {
+		<-stream.done
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for shard := range s.cursors {
+		if shard >= count {
+			delete(s.cursors, shard)
+		}
+	}
+
+	s.count = count
+	for shard := 0; shard < count; shard++ {
+		if _, ok := s.shards[shard]; !ok {
+			s.startShard(shard)
+		}
+	}
+}
+
+// Close stops every shard and waits for their streams to end. The event and
+// error channels are closed afterwards.
+func (s *ShardedSubscription) Close() {
+	s.Resize(0)
+	s.wg.Wait()
+	close(s.events)
+	close(s.errors)
+}
+
+// shardURL fills in the url template for a shard
+func (s *ShardedSubscription) shardURL(shard int) string {
+	return strings.NewReplacer(
+		"{shard}", strconv.Itoa(shard),
+		"{count}", strconv.Itoa(s.count),
+	).Replace(s.urlTemplate)
+}
+
+// startShard starts the stream of a shard, it must be called with the mutex held
+func (s *ShardedSubscription) startShard(shard int) {
+	ctx, cancel := context.WithCancel(WithMetadata(s.ctx, Metadata{ShardMetadataKey: strconv.Itoa(shard)}))
+	stream := &shardStream{cancel: cancel, done: make(chan struct{})}
+	s.shards[shard] = stream
+
+	s.wg.Add(1)
+	req, err := http.NewRequest(http.MethodGet, s.shardURL(shard), nil)
+	if err != nil {
+		go func() {
+			defer s.wg.Done()
+			defer close(stream.done)
+			s.forwardError(ctx, shard, err)
+		}()
+		return
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	if cursor := s.cursors[shard]; cursor != "" {
+		req.Header.Set("Last-Event-ID", cursor)
+	}
+
+	eventch, errch, done := s.client.startStream(req)
+	go func() {
+		defer s.wg.Done()
+		defer close(stream.done)
+		for {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				if event.LastEventID != "" {
+					s.mutex.Lock()
+					// a stopped shard mustn't overwrite the cursor of its replacement
+					if ctx.Err() == nil {
+						s.cursors[shard] = event.LastEventID
+					}
+					s.mutex.Unlock()
+				}
+				select {
+				case s.events <- event:
+				case <-ctx.Done():
+				}
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				s.forwardError(ctx, shard, err)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// forwardError passes an error of a shard on, unless the shard was stopped
+func (s *ShardedSubscription) forwardError(ctx context.Context, shard int, err error) {
+	select {
+	case s.errors <- &ShardError{Shard: shard, Err: err}:
+	case <-ctx.Done():
+	}
+}
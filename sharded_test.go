@@ -0,0 +1,66 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedSubscription(t *testing.T) {
+	var mutex sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		paths = append(paths, r.URL.Path)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sub := NewClient(server.Client()).SubscribeSharded(context.Background(), server.URL+"/feed/{shard}-of-{count}", 2)
+
+	var shards []int
+	for i := 0; i < 2; i++ {
+		err := <-sub.Errors()
+		shardErr, ok := err.(*ShardError)
+		assert(t, ok, "expected a *ShardError, got %T", err)
+		shards = append(shards, shardErr.Shard)
+	}
+	sub.Close()
+
+	sort.Ints(shards)
+	sort.Strings(paths)
+	equals(t, []int{0, 1}, shards)
+	equals(t, []string{"/feed/0-of-2", "/feed/1-of-2"}, paths)
+}
+
+func TestShardedSubscription_Close(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("id: 1\ndata: " + r.URL.Path + "\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	sub := NewClient(server.Client()).SubscribeSharded(context.Background(), server.URL+"/feed/{shard}", 1)
+
+	event := <-sub.Events()
+	equals(t, "/feed/0", string(event.Data))
+	equals(t, Metadata{ShardMetadataKey: "0"}, event.Metadata)
+	equals(t, map[int]string{0: "1"}, sub.Cursors())
+
+	closed := make(chan struct{})
+	go func() {
+		sub.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't return after a shard received an event")
+	}
+}
@@ -0,0 +1,47 @@
+//go:build go1.21
+// +build go1.21
+
+package sse
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SlogLogger returns a Logger writing to logger. Records are labelled with
+// the stream they are about, as in Metrics. Connections and reconnects are
+// logged at the info level, disconnections and parse errors at the warn
+// level and dropped events at the debug level.
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Connected(req *http.Request, resp *http.Response) {
+	l.log(req, slog.LevelInfo, "sse connected", slog.Int("status", resp.StatusCode))
+}
+
+func (l slogLogger) Disconnected(req *http.Request, err error) {
+	l.log(req, slog.LevelWarn, "sse disconnected", slog.String("error", err.Error()))
+}
+
+func (l slogLogger) Reconnecting(req *http.Request, delay time.Duration) {
+	l.log(req, slog.LevelInfo, "sse reconnecting", slog.Duration("delay", delay))
+}
+
+func (l slogLogger) ParseError(req *http.Request, err error) {
+	l.log(req, slog.LevelWarn, "sse event not parsed", slog.String("error", err.Error()))
+}
+
+func (l slogLogger) DroppedEvent(req *http.Request, event *Event) {
+	l.log(req, slog.LevelDebug, "sse event dropped", slog.String("id", event.LastEventID), slog.String("type", event.Type))
+}
+
+func (l slogLogger) log(req *http.Request, level slog.Level, msg string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("stream", metricsLabel(req))}, attrs...)
+	l.logger.LogAttrs(req.Context(), level, msg, attrs...)
+}
@@ -0,0 +1,77 @@
+//go:build go1.21
+// +build go1.21
+
+package sse
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestSlogLogger(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("event: control\ndata: nonsense\n\ndata: hello\n\n"))
+	}))
+	defer server.Close()
+
+	var buf syncBuffer
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond), WithLogger(SlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))))
+	c.ControlEventType = "control"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	ok(t, err)
+	eventch, errch, done := c.startStream(req)
+	for r := range collect(eventch, errch) {
+		if r.Event != nil {
+			cancel()
+		}
+	}
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert(t, len(lines) >= 4, "expected at least 4 records, got %q", lines)
+	expected := [][]string{
+		{"level=WARN", `msg="sse disconnected"`, "503"},
+		{"level=INFO", `msg="sse reconnecting"`, "delay=1ms"},
+		{"level=INFO", `msg="sse connected"`, "status=200"},
+		{"level=WARN", `msg="sse event not parsed"`, "nonsense"},
+	}
+	for i, attrs := range expected {
+		for _, attr := range append(attrs, "stream="+strings.TrimPrefix(server.URL, "http://")+"/events") {
+			assert(t, strings.Contains(lines[i], attr), "record %s is missing %s", lines[i], attr)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package sse
+
+import (
+	"sync"
+	"time"
+)
+
+// SlowDelivery describes an event that took longer than Client.DeliveryDeadline
+// to be received by the consumer
+type SlowDelivery struct {
+	// Event is a copy of the event that was delivered late. The copy of a
+	// pooled event has no Data, since the consumer may have released it.
+	Event *Event
+	// Waited is how long the event waited to be received, including the time
+	// spent in the event queue
+	Waited time.Duration
+	// Count is the number of slow deliveries on the stream so far, including this one
+	Count uint64
+}
+
+// slowConsumerDetector counts events exceeding the delivery deadline of a stream.
+// Heartbeats are delivered from their own goroutine, so it is safe for
+// concurrent use. The hook is never called concurrently.
+type slowConsumerDetector struct {
+	deadline time.Duration
+	hook     func(SlowDelivery)
+
+	mutex sync.Mutex
+	count uint64
+}
+
+// newSlowConsumerDetector returns nil when no deadline is configured
+func (c *Client) newSlowConsumerDetector() *slowConsumerDetector {
+	if c.DeliveryDeadline <= 0 {
+		return nil
+	}
+	return &slowConsumerDetector{deadline: c.DeliveryDeadline, hook: c.OnSlowDelivery}
+}
+
+// snapshot copies what observe reports of an event about to be handed to the
+// consumer, who may release a pooled event, and so have it reused, as soon
+// as it has it
+func (d *slowConsumerDetector) snapshot(event *Event) Event {
+	if d == nil || event == nil {
+		return Event{}
+	}
+	copied := *event
+	if copied.pooled {
+		copied.Data, copied.pooled = nil, false
+	}
+	return copied
+}
+
+// observe records how long an event, as copied by snapshot, waited before
+// being received
+func (d *slowConsumerDetector) observe(event Event, waited time.Duration) {
+	if d == nil || waited <= d.deadline {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.count++
+	if d.hook != nil {
+		d.hook(SlowDelivery{Event: &event, Waited: waited, Count: d.count})
+	}
+}
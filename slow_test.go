@@ -0,0 +1,30 @@
+package sse
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_slowConsumerDetector(t *testing.T) {
+	var reported []SlowDelivery
+	c := &Client{
+		DeliveryDeadline: time.Second,
+		OnSlowDelivery:   func(s SlowDelivery) { reported = append(reported, s) },
+	}
+	d := c.newSlowConsumerDetector()
+	event := &Event{Type: "update"}
+
+	d.observe(d.snapshot(event), time.Millisecond)
+	d.observe(d.snapshot(event), 2*time.Second)
+	d.observe(d.snapshot(event), 3*time.Second)
+
+	equals(t, []SlowDelivery{
+		{Event: event, Waited: 2 * time.Second, Count: 1},
+		{Event: event, Waited: 3 * time.Second, Count: 2},
+	}, reported)
+
+	// no deadline, nothing to detect
+	var nilDetector *slowConsumerDetector
+	assert(t, (&Client{}).newSlowConsumerDetector() == nilDetector, "detector should be nil without a deadline")
+	nilDetector.observe(nilDetector.snapshot(event), time.Hour)
+}
@@ -0,0 +1,78 @@
+// Package sseprom exports the stream metrics of an sse.MetricsCollector as a
+// prometheus.Collector. It is a module of its own, so the client only depends
+// on Prometheus where this package is imported.
+package sseprom
+
+import (
+	sse "github.com/mellena1/sse-client-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector reading the Stats of a
+// sse.MetricsCollector on every scrape. Every metric has a "stream" label
+// holding the label of the stream, see sse.WithMetricsLabel.
+type Collector struct {
+	source *sse.MetricsCollector
+
+	events         *prometheus.Desc
+	bytes          *prometheus.Desc
+	connects       *prometheus.Desc
+	reconnects     *prometheus.Desc
+	sinceLastEvent *prometheus.Desc
+	connectLatency *prometheus.Desc
+	eventLatency   *prometheus.Desc
+}
+
+// NewCollector creates a Collector of the metrics of source, named with
+// namespace as their prefix (e.g. "sse" for "sse_events_received_total") if
+// it isn't empty
+func NewCollector(source *sse.MetricsCollector, namespace string) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{"stream"}, nil)
+	}
+	return &Collector{
+		source:         source,
+		events:         desc("events_received_total", "Events received."),
+		bytes:          desc("bytes_read_total", "Bytes read from connections."),
+		connects:       desc("connects_total", "Connections established."),
+		reconnects:     desc("reconnects_total", "Reconnect attempts."),
+		sinceLastEvent: desc("seconds_since_last_event", "Seconds since the last event was received, for streams that received one."),
+		connectLatency: desc("connect_latency_seconds", "Time connections took to get their response."),
+		eventLatency:   desc("event_latency_seconds", "End to end latency of events that have a timestamp."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.events
+	ch <- c.bytes
+	ch <- c.connects
+	ch <- c.reconnects
+	ch <- c.sinceLastEvent
+	ch <- c.connectLatency
+	ch <- c.eventLatency
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for stream, stats := range c.source.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.events, prometheus.CounterValue, float64(stats.Events), stream)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(stats.Bytes), stream)
+		ch <- prometheus.MustNewConstMetric(c.connects, prometheus.CounterValue, float64(stats.Connects), stream)
+		ch <- prometheus.MustNewConstMetric(c.reconnects, prometheus.CounterValue, float64(stats.Reconnects), stream)
+		if !stats.LastEvent.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.sinceLastEvent, prometheus.GaugeValue, stats.SinceLastEvent().Seconds(), stream)
+		}
+		ch <- histogram(c.connectLatency, stats.ConnectLatency, stream)
+		ch <- histogram(c.eventLatency, stats.EventLatency, stream)
+	}
+}
+
+// histogram converts h to a Prometheus histogram in seconds
+func histogram(desc *prometheus.Desc, h sse.Histogram, stream string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(h.Buckets))
+	for i, bound := range h.Buckets {
+		buckets[bound.Seconds()] = h.Counts[i]
+	}
+	return prometheus.MustNewConstHistogram(desc, h.Count, h.Sum.Seconds(), buckets, stream)
+}
@@ -0,0 +1,69 @@
+package sseprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	source := sse.NewMetricsCollector(10*time.Millisecond, 100*time.Millisecond)
+	source.Connected("a", 20*time.Millisecond)
+	source.BytesRead("a", 15)
+	source.EventReceived("a", &sse.Event{})
+	source.EventLatency("a", 5*time.Millisecond)
+	source.Reconnecting("a", sse.ErrStreamIsClosed)
+	source.Connected("b", 5*time.Millisecond)
+
+	collector := NewCollector(source, "sse")
+	registry := prometheus.NewPedanticRegistry()
+	ok(t, registry.Register(collector))
+
+	expected := `
+# HELP sse_bytes_read_total Bytes read from connections.
+# TYPE sse_bytes_read_total counter
+sse_bytes_read_total{stream="a"} 15
+sse_bytes_read_total{stream="b"} 0
+# HELP sse_connect_latency_seconds Time connections took to get their response.
+# TYPE sse_connect_latency_seconds histogram
+sse_connect_latency_seconds_bucket{stream="a",le="0.01"} 0
+sse_connect_latency_seconds_bucket{stream="a",le="0.1"} 1
+sse_connect_latency_seconds_bucket{stream="a",le="+Inf"} 1
+sse_connect_latency_seconds_sum{stream="a"} 0.02
+sse_connect_latency_seconds_count{stream="a"} 1
+sse_connect_latency_seconds_bucket{stream="b",le="0.01"} 1
+sse_connect_latency_seconds_bucket{stream="b",le="0.1"} 1
+sse_connect_latency_seconds_bucket{stream="b",le="+Inf"} 1
+sse_connect_latency_seconds_sum{stream="b"} 0.005
+sse_connect_latency_seconds_count{stream="b"} 1
+# HELP sse_events_received_total Events received.
+# TYPE sse_events_received_total counter
+sse_events_received_total{stream="a"} 1
+sse_events_received_total{stream="b"} 0
+# HELP sse_reconnects_total Reconnect attempts.
+# TYPE sse_reconnects_total counter
+sse_reconnects_total{stream="a"} 1
+sse_reconnects_total{stream="b"} 0
+`
+	ok(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"sse_bytes_read_total", "sse_connect_latency_seconds", "sse_events_received_total", "sse_reconnects_total"))
+
+	// only the stream that received an event has been idle for a while
+	families, err := registry.Gather()
+	ok(t, err)
+	found := false
+	for _, family := range families {
+		if family.GetName() == "sse_seconds_since_last_event" {
+			found = true
+			equals(t, 1, len(family.GetMetric()))
+			equals(t, "a", family.GetMetric()[0].GetLabel()[0].GetValue())
+			assert(t, family.GetMetric()[0].GetGauge().GetValue() >= 0, "expected a positive idle time")
+		}
+	}
+	assert(t, found, "expected sse_seconds_since_last_event")
+	equals(t, 7*2-1, testutil.CollectAndCount(collector))
+}
@@ -0,0 +1,254 @@
+// Package ssetest provides an in-process SSE server for testing clients.
+//
+// A Server plays a script of Steps on every connection, and hands each
+// connection to the test through NextConn so events can be sent and the
+// connection dropped on command:
+//
+//	srv := ssetest.NewServer(ssetest.Send(&sse.Event{LastEventID: "1", Data: []byte("a")}))
+//	defer srv.Close()
+//
+//	srv.NextConn(t).Drop()
+//	srv.NextConn(t)
+//	srv.AssertLastEventIDs(t, "", "1")
+package ssetest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+// Timeout is how long NextConn waits for a connection
+var Timeout = 5 * time.Second
+
+// ErrDropped is returned writing to a dropped connection
+var ErrDropped = errors.New("ssetest: connection dropped")
+
+// Server is an httptest server streaming events to SSE clients
+type Server struct {
+	*httptest.Server
+
+	script []Step
+	conns  chan *Conn
+
+	mutex        sync.Mutex
+	open         map[*Conn]struct{}
+	lastEventIDs []string
+}
+
+// NewServer starts a Server playing script on every connection. Once the
+// script has been played the connection stays open until it is dropped or
+// the client disconnects.
+func NewServer(script ...Step) *Server {
+	s := &Server{
+		script: script,
+		conns:  make(chan *Conn, 64),
+		open:   make(map[*Conn]struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	conn := &Conn{
+		Request: r,
+		w:       w,
+		flusher: w.(http.Flusher),
+		encoder: sse.NewEncoder(w),
+		dropped: make(chan struct{}),
+	}
+
+	s.mutex.Lock()
+	s.open[conn] = struct{}{}
+	s.lastEventIDs = append(s.lastEventIDs, conn.LastEventID())
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.open, conn)
+		s.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	conn.flusher.Flush()
+
+	select {
+	case s.conns <- conn:
+	default:
+		// nobody is waiting for connections
+	}
+
+	if conn.Play(s.script...) != nil {
+		return
+	}
+	select {
+	case <-conn.dropped:
+	case <-r.Context().Done():
+	}
+}
+
+// NextConn returns the next connection made to the server, failing tb if
+// none is made within Timeout
+func (s *Server) NextConn(tb testing.TB) *Conn {
+	tb.Helper()
+	select {
+	case conn := <-s.conns:
+		return conn
+	case <-time.After(Timeout):
+		tb.Fatalf("ssetest: no connection within %s", Timeout)
+		return nil
+	}
+}
+
+// LastEventIDs returns the Last-Event-ID header of every connection made so
+// far, in order, with "" for connections that didn't send one
+func (s *Server) LastEventIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.lastEventIDs...)
+}
+
+// AssertLastEventIDs fails tb unless the connections made so far sent the
+// Last-Event-ID headers ids
+func (s *Server) AssertLastEventIDs(tb testing.TB, ids ...string) {
+	tb.Helper()
+	got := s.LastEventIDs()
+	if len(got) != len(ids) {
+		tb.Fatalf("ssetest: got Last-Event-IDs %q, want %q", got, ids)
+	}
+	for i := range ids {
+		if got[i] != ids[i] {
+			tb.Fatalf("ssetest: got Last-Event-IDs %q, want %q", got, ids)
+		}
+	}
+}
+
+// Drop drops every open connection
+func (s *Server) Drop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.open {
+		conn.Drop()
+	}
+}
+
+// Close drops every open connection and shuts the server down
+func (s *Server) Close() {
+	s.Drop()
+	s.Server.Close()
+}
+
+// Conn is a connection of a client to a Server
+type Conn struct {
+	// Request is the request the client connected with
+	Request *http.Request
+
+	w       http.ResponseWriter
+	flusher http.Flusher
+
+	mutex    sync.Mutex
+	encoder  *sse.Encoder
+	dropped  chan struct{}
+	dropOnce sync.Once
+}
+
+// LastEventID returns the Last-Event-ID header the client connected with
+func (c *Conn) LastEventID() string {
+	return c.Request.Header.Get("Last-Event-ID")
+}
+
+// Send writes an event to the client
+func (c *Conn) Send(event *sse.Event) error {
+	return c.write(func() error { return c.encoder.Encode(event) })
+}
+
+// Comment writes a comment to the client
+func (c *Conn) Comment(text string) error {
+	return c.write(func() error { return c.encoder.Comment(text) })
+}
+
+func (c *Conn) write(encode func() error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	select {
+	case <-c.dropped:
+		return ErrDropped
+	case <-c.Request.Context().Done():
+		return c.Request.Context().Err()
+	default:
+	}
+	if err := encode(); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+// Play runs steps in order, stopping at the first failing one
+func (c *Conn) Play(steps ...Step) error {
+	for _, step := range steps {
+		if err := step(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drop ends the response, so the client sees the stream end
+func (c *Conn) Drop() {
+	c.dropOnce.Do(func() { close(c.dropped) })
+}
+
+// Dropped is closed once the connection is dropped
+func (c *Conn) Dropped() <-chan struct{} {
+	return c.dropped
+}
+
+// Step is a step of a script played on a connection
+type Step func(c *Conn) error
+
+// Send is a Step sending event
+func Send(event *sse.Event) Step {
+	return func(c *Conn) error { return c.Send(event) }
+}
+
+// Data is a Step sending an event with data and no other field
+func Data(data string) Step {
+	return Send(&sse.Event{Data: []byte(data)})
+}
+
+// Comment is a Step sending a comment
+func Comment(text string) Step {
+	return func(c *Conn) error { return c.Comment(text) }
+}
+
+// Sleep is a Step waiting for d, cut short if the connection is dropped or
+// the client disconnects
+func Sleep(d time.Duration) Step {
+	return func(c *Conn) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-c.dropped:
+			return ErrDropped
+		case <-c.Request.Context().Done():
+			return c.Request.Context().Err()
+		}
+	}
+}
+
+// Drop is a Step dropping the connection, ending the script
+func Drop() Step {
+	return func(c *Conn) error {
+		c.Drop()
+		return ErrDropped
+	}
+}
@@ -0,0 +1,72 @@
+package ssetest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	sse "github.com/mellena1/sse-client-go"
+)
+
+func TestServerScript(t *testing.T) {
+	srv := NewServer(
+		Comment("hello"),
+		Send(&sse.Event{LastEventID: "1", Type: "greeting", Data: []byte("hi")}),
+		Sleep(10*time.Millisecond),
+		Data("bye"),
+		Drop(),
+	)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	ok(t, err)
+	defer resp.Body.Close()
+	equals(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	decoder := sse.NewDecoder(resp.Body)
+	var events []*sse.Event
+	for {
+		event, err := decoder.Decode()
+		if err != nil {
+			break
+		}
+		if len(event.Data) > 0 {
+			events = append(events, event)
+		}
+	}
+	equals(t, []*sse.Event{
+		{LastEventID: "1", Type: "greeting", Data: []byte("hi")},
+		{Data: []byte("bye")},
+	}, events)
+}
+
+func TestServerReconnect(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := sse.NewClient(http.DefaultClient, sse.WithReconnect(time.Millisecond))
+	eventch, errch := client.Subscribe(ctx, srv.URL)
+	go func() {
+		for range errch {
+		}
+	}()
+
+	conn := srv.NextConn(t)
+	ok(t, conn.Send(&sse.Event{LastEventID: "1", Data: []byte("a")}))
+	equals(t, "a", string((<-eventch).Data))
+	conn.Drop()
+	<-conn.Dropped()
+	equals(t, ErrDropped, conn.Send(&sse.Event{Data: []byte("lost")}))
+
+	conn = srv.NextConn(t)
+	equals(t, "1", conn.LastEventID())
+	ok(t, conn.Send(&sse.Event{LastEventID: "2", Data: []byte("b")}))
+	equals(t, "b", string((<-eventch).Data))
+
+	srv.Drop()
+	srv.NextConn(t)
+	srv.AssertLastEventIDs(t, "", "1", "2")
+}
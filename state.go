@@ -0,0 +1,117 @@
+package sse
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReadyState is the state of the connection of a stream, like the readyState
+// of the JavaScript EventSource
+type ReadyState int
+
+const (
+	// Connecting is the state until the first connection is open
+	Connecting ReadyState = iota
+	// Open is the state while a connection is open
+	Open
+	// Reconnecting is the state from the end of a connection until the next
+	// one is open, including the reconnect delay
+	Reconnecting
+	// Closed is the state once the stream has ended
+	Closed
+)
+
+func (s ReadyState) String() string {
+	switch s {
+	case Connecting:
+		return "connecting"
+	case Open:
+		return "open"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// stateTracker keeps the ReadyState of a stream and notifies its changes
+type stateTracker struct {
+	mutex sync.Mutex
+	state ReadyState
+	// backoff is the delay of the reconnect waited for, while Reconnecting
+	backoff time.Duration
+	// changes holds the latest change not yet received, older ones are replaced
+	changes chan ReadyState
+}
+
+func newStateTracker() *stateTracker {
+	return &stateTracker{state: Connecting, changes: make(chan ReadyState, 1)}
+}
+
+type stateTrackerKey struct{}
+
+// withStateTracker returns a copy of ctx carrying t, so the stream started
+// from a request with this context keeps its state in t
+func withStateTracker(ctx context.Context, t *stateTracker) context.Context {
+	return context.WithValue(ctx, stateTrackerKey{}, t)
+}
+
+// stateTrackerFromContext returns the tracker stored in ctx, or nil
+func stateTrackerFromContext(ctx context.Context) *stateTracker {
+	t, _ := ctx.Value(stateTrackerKey{}).(*stateTracker)
+	return t
+}
+
+// get returns the current state
+func (t *stateTracker) get() ReadyState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.state
+}
+
+// set changes the state, a nil tracker ignores it. Nothing changes once Closed.
+func (t *stateTracker) set(state ReadyState) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.state == state || t.state == Closed {
+		return
+	}
+	t.state = state
+	t.backoff = 0
+	// drop the change the consumer hasn't received, only the latest matters
+	select {
+	case <-t.changes:
+	default:
+	}
+	t.changes <- state
+	if state == Closed {
+		close(t.changes)
+	}
+}
+
+// getBackoff returns the delay of the reconnect waited for, if any
+func (t *stateTracker) getBackoff() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.backoff
+}
+
+// setBackoff records the delay of the reconnect about to be waited for, a
+// nil tracker ignores it
+func (t *stateTracker) setBackoff(delay time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.state == Reconnecting {
+		t.backoff = delay
+	}
+}
@@ -0,0 +1,212 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Stream is a handle on a running stream, returned by Client.Connect
+type Stream struct {
+	cancel context.CancelFunc
+	events chan *Event
+	done   chan struct{}
+	state  *stateTracker
+	// reconnect asks the stream to reconnect, see Reconnect
+	reconnect reconnectSignal
+	// stats collects the measurements of the stream, see Stats
+	stats *MetricsCollector
+	// types is the set of event types the stream reads, nil if it reads
+	// every event, see On
+	types *eventTypes
+
+	mutex    sync.Mutex
+	err      error
+	handlers map[string]func(*Event)
+}
+
+// Connect starts streaming req and returns a handle on the stream. Unlike
+// Stream, the stream is stopped with the handle's Close, its event channel
+// is closed once it has ended and the state of its connection is tracked.
+// opts override the configuration of the Client for this stream.
+func (c *Client) Connect(req *http.Request, opts ...StreamOption) *Stream {
+	req = applyStreamOptions(req, opts)
+	s := &Stream{
+		events: make(chan *Event),
+		done:   make(chan struct{}),
+		state:  newStateTracker(),
+		// a request made while one is pending is the same request
+		reconnect: make(reconnectSignal, 1),
+		stats:     NewMetricsCollector(),
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	s.cancel = cancel
+	ctx = withReconnectSignal(withStateTracker(ctx, s.state), s.reconnect)
+	ctx = withStreamCollector(ctx, s.stats)
+	if types := eventTypesFromContext(ctx); types != nil {
+		// the stream gets its own set, as On adds to it
+		s.types = types.clone()
+		ctx = context.WithValue(ctx, eventTypesKey{}, s.types)
+	}
+	eventch, errch, done := c.startStream(req.WithContext(ctx))
+
+	go func() {
+		defer close(s.done)
+		defer s.state.set(Closed)
+		defer close(s.events)
+		defer cancel()
+
+		for {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				if handler := s.handler(event.Type); handler != nil {
+					handler(event)
+					continue
+				}
+				select {
+				case s.events <- event:
+				case <-ctx.Done():
+				}
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				s.mutex.Lock()
+				s.err = err
+				s.mutex.Unlock()
+			case <-done:
+				if ctx.Err() != nil {
+					// stopped by Close or the request's context
+					s.mutex.Lock()
+					s.err = nil
+					s.mutex.Unlock()
+				}
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Events returns the events of the stream. It is closed once the stream has ended.
+func (s *Stream) Events() <-chan *Event {
+	return s.events
+}
+
+// Done is closed once the stream has ended
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// State returns the current state of the stream's connection
+func (s *Stream) State() ReadyState {
+	return s.state.get()
+}
+
+// StateChanges notifies the changes of the stream's state. It only holds the
+// latest change not yet received, so a slow receiver skips intermediate
+// states. It is closed after Closed is sent.
+func (s *Stream) StateChanges() <-chan ReadyState {
+	return s.state.changes
+}
+
+// Err returns the error the stream ended with, nil if it was stopped by Close
+// or its request's context. While the stream is running, it returns the last
+// error the stream reconnected after, if any.
+func (s *Stream) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// Stats returns the measurements of the stream so far: events received, bytes
+// read, connects and reconnects, the time of the last event, the backoff of
+// the reconnect it is waiting for and latency histograms. They are kept
+// whether or not the Client has Metrics.
+func (s *Stream) Stats() StreamStats {
+	// the collector of the stream only has the stream's label
+	stats := StreamStats{
+		ConnectLatency: newHistogram(s.stats.buckets),
+		EventLatency:   newHistogram(s.stats.buckets),
+	}
+	for _, collected := range s.stats.Stats() {
+		stats = collected
+	}
+	stats.Backoff = s.state.getBackoff()
+	return stats
+}
+
+// Reconnect closes the current connection of the stream and reconnects right
+// away, resuming from the last event ID, e.g. once the application learns
+// through another channel that the stream went stale. A stream waiting to
+// reconnect stops waiting. It does nothing once the stream has ended.
+func (s *Stream) Reconnect() {
+	select {
+	case s.reconnect <- struct{}{}:
+	default:
+	}
+}
+
+// On calls handler with the events of type eventType, from the next one on,
+// instead of delivering them on Events. Handlers are called one at a time by
+// the stream, which waits for them. A handler registered for a type already
+// handled replaces the previous one. When the stream only reads some event
+// types, see StreamEventTypes, eventType is added to them, so
+//
+//	stream := client.Connect(req, sse.StreamEventTypes())
+//	stream.On("price", onPrice)
+//
+// skips every event but prices.
+func (s *Stream) On(eventType string, handler func(*Event)) {
+	if s.types != nil {
+		s.types.add(eventType)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.handlers == nil {
+		s.handlers = make(map[string]func(*Event))
+	}
+	s.handlers[eventType] = handler
+}
+
+// handler returns the handler registered with On for eventType, or nil
+func (s *Stream) handler(eventType string) func(*Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.handlers[eventType]
+}
+
+// Close stops the stream and waits for it to end. Events not yet received are dropped.
+func (s *Stream) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// errReconnectRequested ends a connection closed by Stream.Reconnect
+var errReconnectRequested = errors.New("reconnect requested")
+
+// reconnectSignal receives the reconnect requests of a stream
+type reconnectSignal chan struct{}
+
+type reconnectSignalKey struct{}
+
+// withReconnectSignal returns a copy of ctx carrying signal, so the stream
+// started from a request with this context reconnects when it receives
+func withReconnectSignal(ctx context.Context, signal reconnectSignal) context.Context {
+	return context.WithValue(ctx, reconnectSignalKey{}, signal)
+}
+
+// reconnectSignalFromContext returns the signal stored in ctx, or nil, which
+// never receives
+func reconnectSignalFromContext(ctx context.Context) reconnectSignal {
+	signal, _ := ctx.Value(reconnectSignalKey{}).(reconnectSignal)
+	return signal
+}
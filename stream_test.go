@@ -0,0 +1,182 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Connect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	stream := NewClient(server.Client()).Connect(req)
+
+	var data []string
+	for event := range stream.Events() {
+		if len(event.Data) > 0 {
+			data = append(data, string(event.Data))
+		}
+	}
+	<-stream.Done()
+	equals(t, []string{"a", "b"}, data)
+	equals(t, ErrStreamIsClosed, stream.Err())
+	ok(t, stream.Close())
+}
+
+func TestStream_Close(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	stream := NewClient(server.Client()).Connect(req)
+
+	equals(t, "a", string((<-stream.Events()).Data))
+	ok(t, stream.Close())
+	_, open := <-stream.Events()
+	assert(t, !open, "events should be closed after Close")
+	ok(t, stream.Err())
+}
+
+func TestStream_State(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1) == 1 {
+			w.Write([]byte("data: a\n\n"))
+			return
+		}
+		w.Write([]byte("data: b\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	client := NewClient(server.Client())
+	client.Reconnect = true
+	client.ReconnectDelay = 50 * time.Millisecond
+	stream := client.Connect(req)
+	equals(t, Connecting, stream.State())
+
+	equals(t, Open, <-stream.StateChanges())
+	equals(t, "a", string((<-stream.Events()).Data))
+	equals(t, Reconnecting, <-stream.StateChanges())
+	equals(t, Open, <-stream.StateChanges())
+	equals(t, "b", string((<-stream.Events()).Data))
+
+	ok(t, stream.Close())
+	equals(t, Closed, stream.State())
+	equals(t, Closed, <-stream.StateChanges())
+	_, open := <-stream.StateChanges()
+	assert(t, !open, "state changes should be closed after Closed")
+}
+
+func TestStream_Reconnect(t *testing.T) {
+	lastEventIDs := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Write([]byte("id: 1\ndata: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	// neither Reconnect nor a short delay is needed to reconnect on demand
+	c := NewClient(server.Client())
+	c.ReconnectDelay = time.Hour
+	stream := c.Connect(req)
+	defer stream.Close()
+
+	equals(t, "a", string((<-stream.Events()).Data))
+	equals(t, "", <-lastEventIDs)
+	stream.Reconnect()
+
+	select {
+	case id := <-lastEventIDs:
+		equals(t, "1", id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't reconnect")
+	}
+	equals(t, "a", string((<-stream.Events()).Data))
+	equals(t, Open, stream.State())
+	ok(t, stream.Err())
+}
+
+func TestStream_Stats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\ndata: b\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	stream := NewClient(server.Client(), WithReconnect(time.Hour)).Connect(req)
+	defer stream.Close()
+	equals(t, StreamStats{ConnectLatency: newHistogram(DefaultLatencyBuckets), EventLatency: newHistogram(DefaultLatencyBuckets)}, stream.Stats())
+
+	<-stream.Events()
+	<-stream.Events()
+	deadline := time.Now().Add(5 * time.Second)
+	for stream.Stats().Backoff == 0 {
+		assert(t, time.Now().Before(deadline), "expected the stream to wait to reconnect")
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := stream.Stats()
+	equals(t, uint64(2), stats.Events)
+	equals(t, uint64(len("data: a\n\ndata: b\n\n")), stats.Bytes)
+	equals(t, uint64(1), stats.Connects)
+	equals(t, uint64(1), stats.ConnectLatency.Count)
+	equals(t, uint64(1), stats.Reconnects)
+	assert(t, !stats.LastEvent.IsZero(), "expected the time of the last event")
+	equals(t, time.Hour, stats.Backoff)
+}
+
+func TestStream_On(t *testing.T) {
+	handled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-handled
+		w.Write([]byte("event: price\ndata: 1\n\nevent: trade\ndata: 2\n\nevent: price\ndata: 3\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	c := NewClient(server.Client())
+
+	for _, test := range []struct {
+		opts   []StreamOption
+		events []string
+	}{
+		// handled events aren't delivered on Events
+		{events: []string{"2"}},
+		// a stream reading no type reads the handled ones
+		{opts: []StreamOption{StreamEventTypes()}},
+	} {
+		stream := c.Connect(req, test.opts...)
+		var prices []string
+		stream.On("price", func(event *Event) { prices = append(prices, string(event.Data)) })
+		handled <- struct{}{}
+
+		var events []string
+		for event := range stream.Events() {
+			events = append(events, string(event.Data))
+		}
+		equals(t, []string{"1", "3"}, prices)
+		equals(t, test.events, events)
+	}
+}
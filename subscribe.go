@@ -0,0 +1,63 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// Subscribe streams the events of url with a GET request, the common case
+// of Stream. The request accepts text/event-stream and asks caches not to
+// serve it. ctx stops the stream once done. If the request can't be built,
+// e.g. for an invalid url, the error is the terminal error of the stream.
+func (c *Client) Subscribe(ctx context.Context, url string, opts ...StreamOption) (<-chan *Event, <-chan error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return failedStream(ctx, err)
+	}
+	setStreamHeaders(req)
+	return c.Stream(req, opts...)
+}
+
+// SubscribePost streams the events sent in response to a POST of body, as
+// done by many APIs streaming results, e.g. completions of language models.
+// body is sent again on every reconnect, it is read into memory unless
+// http.NewRequest can replay it by itself (*bytes.Buffer, *bytes.Reader and
+// *strings.Reader).
+func (c *Client) SubscribePost(ctx context.Context, url, contentType string, body io.Reader, opts ...StreamOption) (<-chan *Event, <-chan error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err == nil && req.GetBody == nil && body != nil {
+		var data []byte
+		if data, err = io.ReadAll(body); err == nil {
+			req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		}
+	}
+	if err != nil {
+		return failedStream(ctx, err)
+	}
+	setStreamHeaders(req)
+	req.Header.Set("Content-Type", contentType)
+	return c.Stream(req, opts...)
+}
+
+// setStreamHeaders sets the headers of a request for a stream
+func setStreamHeaders(req *http.Request) {
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+}
+
+// failedStream returns the channels of a stream that ended with err before starting
+func failedStream(ctx context.Context, err error) (<-chan *Event, <-chan error) {
+	eventch := make(chan *Event)
+	errch := make(chan error)
+	close(eventch)
+	go func() {
+		defer close(errch)
+		select {
+		case errch <- err:
+		case <-ctx.Done():
+		}
+	}()
+	return eventch, errch
+}
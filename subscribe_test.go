@@ -0,0 +1,80 @@
+package sse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Subscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: " + r.Method + " " + r.Header.Get("Accept") + " " + r.Header.Get("Cache-Control") + "\n\n"))
+	}))
+	defer server.Close()
+
+	eventch, errch := NewClient(server.Client()).Subscribe(context.Background(), server.URL, StreamHeader("X-Test", "1"))
+	equals(t, "GET text/event-stream no-cache", string((<-eventch).Data))
+	_, open := <-eventch
+	assert(t, !open, "events should be closed at the end of the stream")
+	equals(t, ErrStreamIsClosed, <-errch)
+}
+
+func TestClient_Subscribe_invalidURL(t *testing.T) {
+	eventch, errch := NewClient(http.DefaultClient).Subscribe(context.Background(), "://nope")
+	_, open := <-eventch
+	assert(t, !open, "events should be closed")
+	assert(t, <-errch != nil, "expected an error for an invalid url")
+	_, open = <-errch
+	assert(t, !open, "errors should be closed after the terminal error")
+}
+
+func TestClient_SubscribePost(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- r.Method + " " + r.Header.Get("Content-Type") + " " + string(body)
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// a plain io.Reader can only be read once, so it has to be buffered to reconnect
+	body := io.MultiReader(strings.NewReader(`{"prompt":`), strings.NewReader(`"hi"}`))
+	results := collect(c.SubscribePost(ctx, server.URL, "application/json", body))
+
+	for connections := 0; connections < 2; {
+		result := <-results
+		if result.Err != nil {
+			equals(t, ErrStreamIsClosed, result.Err)
+			continue
+		}
+		equals(t, "a", string(result.Event.Data))
+		equals(t, `POST application/json {"prompt":"hi"}`, <-bodies)
+		connections++
+	}
+}
+
+func TestClient_BodyNotReplayable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: a\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, io.MultiReader(strings.NewReader("body")))
+	ok(t, err)
+	eventch, errch := NewClient(server.Client(), WithReconnect(time.Millisecond)).Stream(req)
+
+	var errs []error
+	for result := range collect(eventch, errch) {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	equals(t, []error{ErrStreamIsClosed, ErrBodyNotReplayable}, errs)
+}
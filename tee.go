@@ -0,0 +1,45 @@
+package sse
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type teeKey struct{}
+
+// WithTee returns a copy of ctx carrying w. Streams started from a request
+// with this context copy their raw bytes to w instead of Client.Tee, so every
+// stream can be recorded separately.
+func WithTee(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, teeKey{}, w)
+}
+
+// teeFromContext returns the writer stored in ctx by WithTee, or nil
+func teeFromContext(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(teeKey{}).(io.Writer)
+	return w
+}
+
+// lockedWriter serializes the writes of several streams to a shared writer
+type lockedWriter struct {
+	mutex *sync.Mutex
+	w     io.Writer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.w.Write(p)
+}
+
+// tee returns the writer receiving the raw bytes of a stream, if any
+func (c *Client) tee(ctx context.Context) io.Writer {
+	if w := teeFromContext(ctx); w != nil {
+		return w
+	}
+	if c.Tee != nil {
+		return lockedWriter{mutex: &c.teeMutex, w: c.Tee}
+	}
+	return nil
+}
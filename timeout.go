@@ -0,0 +1,57 @@
+package sse
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrConnectTimeout is passed through the error channel when a connection
+	// attempt didn't get the response headers within the Client's
+	// ConnectTimeout
+	ErrConnectTimeout = errors.New("no response from stream within the connect timeout")
+
+	// ErrFirstEventTimeout is passed through the error channel when an open
+	// connection didn't deliver its first event within the Client's
+	// FirstEventTimeout
+	ErrFirstEventTimeout = errors.New("no event received from stream within the first event timeout")
+
+	// errConnectionRotated ends a connection open for the Client's MaxConnectionAge
+	errConnectionRotated = errors.New("connection reached its maximum age")
+)
+
+// connDeadline aborts a connection, with cancel, unless it is stopped within
+// its timeout. A nil connDeadline never expires.
+type connDeadline struct {
+	timer   *time.Timer
+	expired int32
+}
+
+// startDeadline starts a deadline of timeout, none if timeout isn't positive
+func startDeadline(timeout time.Duration, cancel func()) *connDeadline {
+	if timeout <= 0 {
+		return nil
+	}
+	d := &connDeadline{}
+	d.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&d.expired, 1)
+		cancel()
+	})
+	return d
+}
+
+// stop stops the deadline, reporting whether it had expired already, in
+// which case the connection is being aborted
+func (d *connDeadline) stop() bool {
+	if d == nil {
+		return false
+	}
+	d.timer.Stop()
+	return d.hasExpired()
+}
+
+// hasExpired reports whether the deadline aborted the connection
+func (d *connDeadline) hasExpired() bool {
+	return d != nil && atomic.LoadInt32(&d.expired) == 1
+}
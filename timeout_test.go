@@ -0,0 +1,88 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Timeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			// the headers never come
+			<-r.Context().Done()
+			return
+		case "/quiet":
+			w.Write([]byte(": keep-alive\n\n"))
+		case "/events":
+			w.Write([]byte("data: a\n\n"))
+		}
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(200 * time.Millisecond):
+			w.Write([]byte("data: b\n\n"))
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		expected []Result
+	}{
+		{"/slow", []Result{{Err: ErrConnectTimeout}}},
+		{"/quiet", []Result{{Err: ErrFirstEventTimeout}}},
+		// only the first event has to come in time
+		{"/events", []Result{{Event: &Event{Data: []byte("a")}}, {Event: &Event{Data: []byte("b")}}, {Err: ErrStreamIsClosed}}},
+	}
+
+	for _, test := range tests {
+		c := NewClient(server.Client(), WithTimeouts(50*time.Millisecond, 50*time.Millisecond))
+		req, err := http.NewRequest(http.MethodGet, server.URL+test.path, nil)
+		ok(t, err)
+
+		start := time.Now()
+		var results []Result
+		for r := range collect(c.Stream(req)) {
+			results = append(results, r)
+		}
+		equals(t, test.expected, results)
+		assert(t, time.Since(start) < 2*time.Second, "%s: took %s", test.path, time.Since(start))
+	}
+}
+
+func TestClient_MaxConnectionAge(t *testing.T) {
+	lastEventIDs := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Write([]byte("id: 1\ndata: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	// rotating doesn't wait the reconnect delay, nor need Reconnect
+	c := NewClient(server.Client(), WithMaxConnectionAge(50*time.Millisecond))
+	c.ReconnectDelay = time.Hour
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	stream := c.Connect(req)
+	defer stream.Close()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-stream.Events():
+			equals(t, "a", string(event.Data))
+		case <-time.After(5 * time.Second):
+			t.Fatal("the connection wasn't rotated")
+		}
+	}
+	equals(t, "", <-lastEventIDs)
+	equals(t, "1", <-lastEventIDs)
+	equals(t, "1", <-lastEventIDs)
+	ok(t, stream.Err())
+	reconnects := stream.Stats().Reconnects
+	assert(t, reconnects >= 2, "expected the rotations to be counted as reconnects, got %d", reconnects)
+}
@@ -0,0 +1,86 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setTimestamp fills in event.Timestamp from the configured field or json path
+// and reports the end to end latency to the LatencyObserver and the metrics
+func (c *Client) setTimestamp(event *Event, eventBytes []byte, metrics streamMetrics) {
+	var raw string
+	switch {
+	case c.TimestampField != "":
+		value, ok := findField(eventBytes, []byte(c.TimestampField))
+		if !ok {
+			return
+		}
+		raw = string(value)
+	case c.TimestampJSONPath != "":
+		value, ok := findJSONPath(event.Data, c.TimestampJSONPath)
+		if !ok {
+			return
+		}
+		raw = value
+	default:
+		return
+	}
+
+	ts, err := parseTimestamp(raw)
+	if err != nil {
+		return
+	}
+	event.Timestamp = ts
+
+	latency := time.Since(ts)
+	if c.LatencyObserver != nil {
+		c.LatencyObserver(event, latency)
+	}
+	metrics.eventLatency(latency)
+}
+
+// findField returns the value of the last occurrence of field in an event block
+func findField(data, field []byte) ([]byte, bool) {
+	var value []byte
+	found := false
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		// a line without a colon is a field with an empty value
+		name, rest := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			name, rest = line[:i], line[i+1:]
+		}
+		if !bytes.Equal(name, field) {
+			continue
+		}
+		value = bytes.TrimPrefix(rest, []byte(" "))
+		found = true
+	}
+	return value, found
+}
+
+// parseTimestamp parses either an RFC 3339 time or a unix epoch number.
+// Epoch numbers of 1e12 or more are treated as milliseconds.
+func parseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("timestamp is empty")
+	}
+
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil {
+		if epoch >= 1e12 {
+			return time.Unix(0, int64(epoch*float64(time.Millisecond))), nil
+		}
+		return time.Unix(0, int64(epoch*float64(time.Second))), nil
+	}
+
+	return time.Parse(time.RFC3339Nano, value)
+}
@@ -0,0 +1,38 @@
+package sse
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseTimestamp(t *testing.T) {
+	tests := []struct {
+		testname  string
+		input     string
+		expected  time.Time
+		shouldErr bool
+	}{
+		{"rfc3339", "2020-01-02T03:04:05Z", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{"unix seconds", "1577934245", time.Unix(1577934245, 0), false},
+		{"unix millis", "1577934245500", time.Unix(1577934245, 500*int64(time.Millisecond)), false},
+		{"garbage", "yesterday", time.Time{}, true},
+		{"empty", "", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		actual, err := parseTimestamp(test.input)
+		if !test.shouldErr {
+			ok(t, err)
+			assert(t, test.expected.Equal(actual), "%s: expected %s, got %s", test.testname, test.expected, actual)
+		} else {
+			assert(t, err != nil, "should've errored but didn't")
+		}
+	}
+}
+
+func Test_setTimestamp(t *testing.T) {
+	c := &Client{TimestampField: "ts"}
+	event := &Event{}
+	c.setTimestamp(event, []byte("data: hi\nts: 2020-01-02T03:04:05Z\n"), streamMetrics{})
+	assert(t, event.Timestamp.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), "unexpected timestamp %s", event.Timestamp)
+}
@@ -0,0 +1,172 @@
+package sse
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+)
+
+// StreamTrace is a set of hooks run at various stages of a stream, like
+// httptrace.ClientTrace is for HTTP requests. Any particular hook may be
+// nil. Hooks are called from the goroutine of the stream, so they must
+// return quickly.
+type StreamTrace struct {
+	// GotResponse is called with the response of every connection attempt,
+	// before its status is checked
+	GotResponse func(resp *http.Response)
+	// Connected is called with the response of every connection opened, once
+	// it is accepted as a stream, before any event is read from it
+	Connected func(resp *http.Response)
+	// GotRawLine is called with every line read from the stream, without
+	// its line ending. The line is only valid during the call.
+	GotRawLine func(line []byte)
+	// GotComment is called with the text of every comment line
+	GotComment func(comment string)
+	// DispatchedEvent is called with every event as it is dispatched to the
+	// consumer, error and control events aside
+	DispatchedEvent func(event *Event)
+	// WillReconnect is called with the error a connection ended with and the
+	// delay before reconnecting
+	WillReconnect func(err error, delay time.Duration)
+}
+
+type streamTraceKey struct{}
+
+// WithStreamTrace returns a copy of ctx carrying trace. Streams started from
+// a request with this context run its hooks. If ctx already carries a
+// StreamTrace, the hooks of trace are run before its own.
+func WithStreamTrace(ctx context.Context, trace *StreamTrace) context.Context {
+	if old := ContextStreamTrace(ctx); old != nil {
+		trace = trace.compose(old)
+	}
+	return context.WithValue(ctx, streamTraceKey{}, trace)
+}
+
+// ContextStreamTrace returns the StreamTrace stored in ctx by
+// WithStreamTrace, or nil
+func ContextStreamTrace(ctx context.Context) *StreamTrace {
+	trace, _ := ctx.Value(streamTraceKey{}).(*StreamTrace)
+	return trace
+}
+
+// compose returns a trace running the hooks of t, then those of old
+func (t *StreamTrace) compose(old *StreamTrace) *StreamTrace {
+	composed := *t
+	if old.GotResponse != nil {
+		f, g := composed.GotResponse, old.GotResponse
+		composed.GotResponse = func(resp *http.Response) {
+			if f != nil {
+				f(resp)
+			}
+			g(resp)
+		}
+	}
+	if old.Connected != nil {
+		f, g := composed.Connected, old.Connected
+		composed.Connected = func(resp *http.Response) {
+			if f != nil {
+				f(resp)
+			}
+			g(resp)
+		}
+	}
+	if old.GotRawLine != nil {
+		f, g := composed.GotRawLine, old.GotRawLine
+		composed.GotRawLine = func(line []byte) {
+			if f != nil {
+				f(line)
+			}
+			g(line)
+		}
+	}
+	if old.GotComment != nil {
+		f, g := composed.GotComment, old.GotComment
+		composed.GotComment = func(comment string) {
+			if f != nil {
+				f(comment)
+			}
+			g(comment)
+		}
+	}
+	if old.DispatchedEvent != nil {
+		f, g := composed.DispatchedEvent, old.DispatchedEvent
+		composed.DispatchedEvent = func(event *Event) {
+			if f != nil {
+				f(event)
+			}
+			g(event)
+		}
+	}
+	if old.WillReconnect != nil {
+		f, g := composed.WillReconnect, old.WillReconnect
+		composed.WillReconnect = func(err error, delay time.Duration) {
+			if f != nil {
+				f(err, delay)
+			}
+			g(err, delay)
+		}
+	}
+	return &composed
+}
+
+// gotResponse runs the GotResponse hook of t, if any
+func (t *StreamTrace) gotResponse(resp *http.Response) {
+	if t != nil && t.GotResponse != nil {
+		t.GotResponse(resp)
+	}
+}
+
+// connected runs the Connected hook of t, if any
+func (t *StreamTrace) connected(resp *http.Response) {
+	if t != nil && t.Connected != nil {
+		t.Connected(resp)
+	}
+}
+
+// gotBlock runs the GotRawLine and GotComment hooks of t for the lines of an
+// event block
+func (t *StreamTrace) gotBlock(block []byte) {
+	if t == nil || (t.GotRawLine == nil && t.GotComment == nil) {
+		return
+	}
+	for _, line := range bytes.FieldsFunc(block, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if t.GotRawLine != nil {
+			t.GotRawLine(line)
+		}
+		if comment, ok := commentText(line); ok && t.GotComment != nil {
+			t.GotComment(comment)
+		}
+	}
+}
+
+// commentText returns the text of a comment line
+func commentText(line []byte) (string, bool) {
+	if !bytes.HasPrefix(line, []byte(":")) {
+		return "", false
+	}
+	return string(bytes.TrimPrefix(line[1:], []byte(" "))), true
+}
+
+// comments calls f with the text of every comment line of an event block
+func comments(block []byte, f func(comment string)) {
+	for _, line := range bytes.FieldsFunc(block, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if comment, ok := commentText(line); ok {
+			f(comment)
+		}
+	}
+}
+
+// dispatchedEvent runs the DispatchedEvent hook of t, if any
+func (t *StreamTrace) dispatchedEvent(event *Event) {
+	if t != nil && t.DispatchedEvent != nil {
+		t.DispatchedEvent(event)
+	}
+}
+
+// willReconnect runs the WillReconnect hook of t, if any
+func (t *StreamTrace) willReconnect(err error, delay time.Duration) {
+	if t != nil && t.WillReconnect != nil {
+		t.WillReconnect(err, delay)
+	}
+}
@@ -0,0 +1,65 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(": keep-alive\n\nid: 1\r\ndata: hello\r\n\r\n"))
+	}))
+	defer server.Close()
+
+	var mutex sync.Mutex
+	var got []string
+	record := func(s string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		got = append(got, s)
+	}
+	trace := &StreamTrace{
+		GotResponse:     func(resp *http.Response) { record("response " + resp.Status) },
+		GotRawLine:      func(line []byte) { record("line " + string(line)) },
+		GotComment:      func(comment string) { record("comment " + comment) },
+		DispatchedEvent: func(event *Event) { record("event " + event.LastEventID) },
+		WillReconnect:   func(err error, delay time.Duration) { record("reconnect " + err.Error() + " " + delay.String()) },
+	}
+	var outer []string
+	ctx, cancel := context.WithCancel(WithStreamTrace(context.Background(), &StreamTrace{
+		DispatchedEvent: func(event *Event) { outer = append(outer, event.LastEventID) },
+	}))
+	defer cancel()
+
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+	req, err := http.NewRequestWithContext(WithStreamTrace(ctx, trace), http.MethodGet, server.URL, nil)
+	ok(t, err)
+	eventch, errch, done := c.startStream(req)
+	for r := range collect(eventch, errch) {
+		if r.Event != nil && r.Event.LastEventID == "1" {
+			cancel()
+		}
+	}
+	<-done
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert(t, len(got) >= 6, "expected at least 6 hooks run, got %q", got)
+	equals(t, []string{
+		"response 200 OK",
+		"line : keep-alive",
+		"comment keep-alive",
+		"line id: 1",
+		"line data: hello",
+		"event 1",
+	}, got[:6])
+	if len(got) > 6 {
+		assert(t, strings.HasPrefix(got[6], "reconnect "+ErrStreamIsClosed.Error()+" 1ms"), "unexpected hook %q", got[6])
+	}
+	equals(t, "1", outer[0])
+}
@@ -0,0 +1,106 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer starts the spans tracing the lifecycle of streams, see
+// Client.Tracer. It mirrors the part of the OpenTelemetry tracing API the
+// client uses, so an adapter over an otel trace.Tracer takes a few lines
+// without the client depending on otel.
+//
+// A stream is traced by an "sse.stream" span, started from the context of
+// its request and ended with the stream, with an "sse.connect" child span
+// for every connection attempt.
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	AddEvent(name string, attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair annotating a span or one of its events. Value
+// is a string, bool, int or int64.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+type traceSpanKey struct{}
+
+// traceSpan is a span of the Client's Tracer, doing nothing if there is none
+type traceSpan struct {
+	span Span
+}
+
+// startSpan starts a span named name as a child of the span of ctx. The
+// returned context carries the span, so connection attempts made with it
+// are traced as its children.
+func (c *Client) startSpan(ctx context.Context, name string, req *http.Request) (context.Context, traceSpan) {
+	if c.Tracer == nil {
+		return ctx, traceSpan{}
+	}
+	ctx, span := c.Tracer.Start(ctx, name)
+	span.SetAttributes(
+		Attribute{Key: "http.request.method", Value: req.Method},
+		Attribute{Key: "server.address", Value: req.URL.Host},
+		Attribute{Key: "url.path", Value: req.URL.Path},
+	)
+	s := traceSpan{span: span}
+	return context.WithValue(ctx, traceSpanKey{}, s), s
+}
+
+// traceSpanFromContext returns the span stored in ctx by startSpan, if any
+func traceSpanFromContext(ctx context.Context) traceSpan {
+	s, _ := ctx.Value(traceSpanKey{}).(traceSpan)
+	return s
+}
+
+func (s traceSpan) setAttributes(attrs ...Attribute) {
+	if s.span != nil {
+		s.span.SetAttributes(attrs...)
+	}
+}
+
+func (s traceSpan) addEvent(name string, attrs ...Attribute) {
+	if s.span != nil {
+		s.span.AddEvent(name, attrs...)
+	}
+}
+
+// end ends the span, recording err unless the stream was stopped or a
+// control event ended the connection
+func (s traceSpan) end(err error) {
+	if s.span == nil {
+		return
+	}
+	if ctrlErr, ok := err.(*controlError); ok {
+		s.span.SetAttributes(Attribute{Key: "sse.control", Value: ctrlErr.ctrl.Directive})
+	} else if err != nil && err != errStreamStopped {
+		s.span.RecordError(err)
+	}
+	s.span.End()
+}
+
+// traceConnect starts the span of a connection attempt, returning req with
+// its context
+func (c *Client) traceConnect(req *http.Request, state *streamState) (*http.Request, traceSpan) {
+	if c.Tracer == nil {
+		return req, traceSpan{}
+	}
+	ctx, span := c.startSpan(req.Context(), "sse.connect", req)
+	span.setAttributes(
+		Attribute{Key: "sse.reconnect", Value: state.reconnecting},
+		Attribute{Key: "sse.retry_count", Value: state.attempts},
+	)
+	if lastEventID := req.Header.Get("Last-Event-ID"); lastEventID != "" {
+		span.setAttributes(Attribute{Key: "sse.last_event_id", Value: lastEventID})
+	}
+	return req.WithContext(ctx), span
+}
@@ -0,0 +1,133 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingTracer keeps the spans it starts
+type recordingTracer struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	events []string
+	errs   []error
+	ended  bool
+}
+
+type recordedSpanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	parent, _ := ctx.Value(recordedSpanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), tracedSpan{t, span}
+}
+
+func (t *recordingTracer) snapshot() []recordedSpan {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	spans := make([]recordedSpan, len(t.spans))
+	for i, span := range t.spans {
+		spans[i] = *span
+	}
+	return spans
+}
+
+type tracedSpan struct {
+	tracer *recordingTracer
+	span   *recordedSpan
+}
+
+func (s tracedSpan) SetAttributes(attrs ...Attribute) {
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	for _, attr := range attrs {
+		s.span.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s tracedSpan) AddEvent(name string, attrs ...Attribute) {
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.span.events = append(s.span.events, name)
+}
+
+func (s tracedSpan) RecordError(err error) {
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.span.errs = append(s.span.errs, err)
+}
+
+func (s tracedSpan) End() {
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.span.ended = true
+}
+
+func TestClient_Tracer(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("id: 1\ndata: one\n\nid: 2\ndata: two\n\n"))
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	c := NewClient(server.Client(), WithReconnect(time.Millisecond))
+	c.Tracer = tracer
+	c.TraceEvents = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	ok(t, err)
+	eventch, errch, done := c.startStream(req)
+
+	received := 0
+	for r := range collect(eventch, errch) {
+		if r.Event != nil {
+			if received++; received == 2 {
+				cancel()
+			}
+		}
+	}
+	<-done
+
+	spans := tracer.snapshot()
+	assert(t, len(spans) >= 3, "expected a stream span and two connect spans, got %d spans", len(spans))
+	stream := spans[0]
+	equals(t, "sse.stream", stream.name)
+	equals(t, "/events", stream.attrs["url.path"])
+	assert(t, stream.ended, "stream span not ended")
+	equals(t, 0, len(stream.errs))
+
+	rejected, open := spans[1], spans[2]
+	for _, span := range []recordedSpan{rejected, open} {
+		equals(t, "sse.connect", span.name)
+		assert(t, span.parent != nil && span.parent.name == "sse.stream", "connect span isn't a child of the stream span")
+		assert(t, span.ended, "connect span not ended")
+	}
+	equals(t, http.StatusServiceUnavailable, rejected.attrs["http.response.status_code"])
+	equals(t, false, rejected.attrs["sse.reconnect"])
+	equals(t, 1, len(rejected.errs))
+	equals(t, http.StatusOK, open.attrs["http.response.status_code"])
+	equals(t, true, open.attrs["sse.reconnect"])
+	equals(t, 1, open.attrs["sse.retry_count"])
+	equals(t, []string{"sse.event", "sse.event"}, open.events[:2])
+}
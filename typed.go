@@ -0,0 +1,89 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// TypedEvent is an event along with its data decoded into a T
+type TypedEvent[T any] struct {
+	Event *Event
+	Value T
+}
+
+// DecodeError is passed through the error channel of a typed subscription
+// when the data of an event couldn't be decoded. The event isn't delivered.
+// It is also returned by Event.UnmarshalData.
+type DecodeError struct {
+	Event *Event
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	eventType := e.Event.Type
+	if eventType == "" {
+		eventType = MessageEventType
+	}
+	if e.Event.LastEventID == "" {
+		return fmt.Sprintf("decoding %s event: %s", eventType, e.Err.Error())
+	}
+	return fmt.Sprintf("decoding %s event %q: %s", eventType, e.Event.LastEventID, e.Err.Error())
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// SubscribeCodec streams req with client and decodes the data of every event
+// into a T with codec. Events without data, such as heartbeats, are skipped.
+// Errors of the stream and *DecodeError are passed through the error channel.
+// Both channels are closed once the stream has ended; cancel ctx to stop it.
+func SubscribeCodec[T any](ctx context.Context, client *Client, req *http.Request, codec Codec) (<-chan TypedEvent[T], <-chan error) {
+	eventch, errch, done := client.startStream(req.WithContext(ctx))
+	typedch := make(chan TypedEvent[T])
+	typederrch := make(chan error)
+
+	go func() {
+		defer close(typedch)
+		defer close(typederrch)
+
+		for {
+			select {
+			case event, open := <-eventch:
+				if !open {
+					eventch = nil
+					continue
+				}
+				if len(event.Data) == 0 {
+					continue
+				}
+				var value T
+				if err := codec.Decode(event.Data, &value); err != nil {
+					select {
+					case typederrch <- &DecodeError{Event: event, Err: err}:
+					case <-ctx.Done():
+					}
+					continue
+				}
+				select {
+				case typedch <- TypedEvent[T]{Event: event, Value: value}:
+				case <-ctx.Done():
+				}
+			case err, open := <-errch:
+				if !open {
+					errch = nil
+					continue
+				}
+				select {
+				case typederrch <- err:
+				case <-ctx.Done():
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return typedch, typederrch
+}
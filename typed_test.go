@@ -0,0 +1,76 @@
+//go:build !tinygo && !sse_minimal
+// +build !tinygo,!sse_minimal
+
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type price struct {
+	Symbol string  `json:"symbol"`
+	Value  float64 `json:"value"`
+}
+
+func TestSubscribeJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"symbol\":\"ABC\",\"value\":1.5}\n\n: keep-alive\n\ndata: not json\n\ndata: {\"symbol\":\"XYZ\",\"value\":2}\n\n"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	client := NewClient(server.Client())
+	client.HeartbeatOnComment = true
+	events, errs := SubscribeJSON[price](context.Background(), client, req)
+
+	var prices []price
+	var decodeErrors int
+	for events != nil || errs != nil {
+		select {
+		case event, open := <-events:
+			if !open {
+				events = nil
+				continue
+			}
+			prices = append(prices, event.Value)
+		case err, open := <-errs:
+			if !open {
+				errs = nil
+				continue
+			}
+			var decodeErr *DecodeError
+			if errors.As(err, &decodeErr) {
+				equals(t, "not json", string(decodeErr.Event.Data))
+				decodeErrors++
+			} else {
+				equals(t, ErrStreamIsClosed, err)
+			}
+		}
+	}
+	equals(t, []price{{"ABC", 1.5}, {"XYZ", 2}}, prices)
+	equals(t, 1, decodeErrors)
+}
+
+func TestSubscribeJSON_cancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"symbol\":\"ABC\"}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	ok(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _ := SubscribeJSON[price](ctx, NewClient(server.Client()), req)
+
+	equals(t, "ABC", (<-events).Value.Symbol)
+	cancel()
+	for range events {
+	}
+}
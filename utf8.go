@@ -0,0 +1,35 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrInvalidUTF8 is passed through the error channel in place of events that
+// aren't valid UTF-8, with UTF8Error
+var ErrInvalidUTF8 = errors.New("event is not valid UTF-8")
+
+// UTF8Policy is how events that aren't valid UTF-8 are handled, since the
+// spec requires streams to be UTF-8
+type UTF8Policy int
+
+const (
+	// UTF8PassThrough delivers invalid bytes as they are
+	UTF8PassThrough UTF8Policy = iota
+	// UTF8Replace replaces invalid bytes with U+FFFD, as browsers do
+	UTF8Replace
+	// UTF8Error drops invalid events, reporting ErrInvalidUTF8
+	UTF8Error
+)
+
+// apply applies the policy to an event block
+func (p UTF8Policy) apply(block []byte) ([]byte, error) {
+	if p == UTF8PassThrough || utf8.Valid(block) {
+		return block, nil
+	}
+	if p == UTF8Replace {
+		return bytes.ToValidUTF8(block, []byte("\uFFFD")), nil
+	}
+	return nil, ErrInvalidUTF8
+}